@@ -4,7 +4,9 @@ go 1.26
 
 require (
 	github.com/gomarkdown/markdown v0.0.0-20241205020045-f7e15b2f3e62
+	github.com/klauspost/compress v1.18.0
 	github.com/stretchr/testify v1.11.1
+	github.com/ulikunitz/xz v0.5.15
 )
 
 require (
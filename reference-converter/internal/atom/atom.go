@@ -6,67 +6,105 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/fetch"
 )
 
-type entry struct {
-	Link struct {
+// Entry is a commit in the atom feed of a repository, e.g.
+// https://github.com/nginx/nginx.org/commits/main.atom
+type Entry struct {
+	ID      string    `xml:"id"`
+	Title   string    `xml:"title"`
+	Author  Author    `xml:"author"`
+	Updated time.Time `xml:"updated"`
+	Link    struct {
 		Href string `xml:"href,attr"`
 	} `xml:"link"`
 }
 
+type Author struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email"`
+	URI   string `xml:"uri"`
+}
+
+// commitID matches the commit SHA at the end of an entry id or link, like
+// "tag:github.com,2008:Grit::Commit/<sha>" or "...#changeset-<sha>".
+var commitID = regexp.MustCompile(`[0-9a-f]{7,40}$`)
+
+// Commit returns the SHA of the commit, from the id of the entry or its link.
+func (e Entry) Commit() string {
+	if id := commitID.FindString(e.ID); id != "" {
+		return id
+	}
+	return commitID.FindString(e.Link.Href)
+}
+
 type feed struct {
-	Entry []entry `xml:"entry"`
+	Entry []Entry `xml:"entry"`
 }
 
 type config struct {
-	Client http.Client
+	fetch []fetch.Option
 }
 type Option = func(*config)
 
 // WithHttpClient uses the provided client instead of the default for
 // downloading tarballs.
 func WithHttpClient(c http.Client) Option {
-	return func(o *config) { o.Client = c }
+	return WithFetch(fetch.WithHttpClient(c))
 }
 
-// GetVersion gets the first link of the first entry of the XML file
-func GetVersion(ctx context.Context, url string, opts ...Option) (string, error) {
+// WithFetch configures downloads, e.g. to go through a cache.
+func WithFetch(opts ...fetch.Option) Option {
+	return func(o *config) { o.fetch = append(o.fetch, opts...) }
+}
+
+// GetEntries gets all the entries of the feed, newest first.
+func GetEntries(ctx context.Context, url string, opts ...Option) ([]Entry, error) {
 	cfg := &config{}
 	for _, opt := range opts {
 		opt(cfg)
 	}
-	body, _ := openURL(ctx, url, cfg.Client)
-	return parseXML(body)
-}
-func openURL(ctx context.Context, url string, client http.Client) ([]byte, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	body, err := openURL(ctx, url, cfg.fetch)
 	if err != nil {
 		return nil, err
 	}
-	res, err := client.Do(req)
+	return parseXML(body)
+}
+
+// GetLatest gets the newest entry of the feed.
+func GetLatest(ctx context.Context, url string, opts ...Option) (Entry, error) {
+	entries, err := GetEntries(ctx, url, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("unable to download %s: %w", url, err)
+		return Entry{}, err
+	}
+	if len(entries) == 0 {
+		return Entry{}, fmt.Errorf("no entry was found in the feed")
 	}
-	defer res.Body.Close() //nolint:errcheck // nothing to do about it
+	return entries[0], nil
+}
 
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unable to download %s: %s", url, res.Status)
+func openURL(ctx context.Context, url string, opts []fetch.Option) ([]byte, error) {
+	res, err := fetch.Get(ctx, url, opts...)
+	if err != nil {
+		return nil, err
 	}
+	defer res.Close() //nolint:errcheck // nothing to do about it
 
-	body, err := io.ReadAll(res.Body)
+	body, err := io.ReadAll(res)
 	if err != nil {
 		return nil, fmt.Errorf("unable to read response body: %w", err)
 	}
 	return body, nil
 }
-func parseXML(XMLContent []byte) (string, error) {
+func parseXML(XMLContent []byte) ([]Entry, error) {
 	var f feed
 	err := xml.Unmarshal(XMLContent, &f)
 	if err != nil {
-		return "", fmt.Errorf("unable to parse XML: %w", err)
-	}
-	if len(f.Entry) > 0 {
-		return f.Entry[0].Link.Href, nil
+		return nil, fmt.Errorf("unable to parse XML: %w", err)
 	}
-	return "", fmt.Errorf("no entry was found in the feed")
+	return f.Entry, nil
 }
@@ -6,8 +6,10 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/atom"
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/fetch"
 	"github.com/stretchr/testify/require"
 )
 
@@ -17,14 +19,14 @@ func readTestDataFile(t *testing.T) string {
 	require.NoError(t, err)
 	return string(body)
 }
-func TestGetVersion(t *testing.T) {
+func TestGetLatest(t *testing.T) {
 	t.Parallel()
 
 	testCases := map[string]struct {
 		statusCode   int
 		responseBody string
 		wantError    bool
-		want         string
+		want         string // commit SHA
 	}{
 		"BadStatus": {
 			statusCode:   http.StatusBadRequest,
@@ -35,7 +37,7 @@ func TestGetVersion(t *testing.T) {
 			statusCode:   http.StatusOK,
 			responseBody: readTestDataFile(t),
 			wantError:    false,
-			want:         "http://hg.nginx.org/nginx.org/rev/c80a7cb452e8",
+			want:         "c80a7cb452e83963d5f798a5c7787ac600978dd3",
 		},
 		"OKStatusWithNoEntries": {
 			statusCode:   http.StatusOK,
@@ -60,15 +62,69 @@ func TestGetVersion(t *testing.T) {
 			}))
 			defer srv.Close()
 
-			got, err := atom.GetVersion(context.Background(), srv.URL, atom.WithHttpClient(*srv.Client()))
+			got, err := atom.GetLatest(context.Background(), srv.URL, atom.WithHttpClient(*srv.Client()))
 
 			if testCase.wantError {
 				require.Error(t, err)
 			} else {
 				require.NoError(t, err)
-				require.Equal(t, testCase.want, got)
+				require.Equal(t, testCase.want, got.Commit())
 			}
 
 		})
 	}
 }
+
+func TestGetEntries(t *testing.T) {
+	t.Parallel()
+	// the shape of https://github.com/nginx/nginx.org/commits/main.atom
+	body := `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:media="http://search.yahoo.com/mrss/" xml:lang="en-US">
+  <id>tag:github.com,2008:/nginx/nginx.org/commits/main</id>
+  <title>Recent Commits to nginx.org:main</title>
+  <updated>2025-01-21T14:12:02Z</updated>
+  <entry>
+    <id>tag:github.com,2008:Grit::Commit/5f1d3bb1cbd5b8e7a9a4b8b2f0bd3d9a1f0c6e21</id>
+    <link type="text/html" rel="alternate" href="https://github.com/nginx/nginx.org/commit/5f1d3bb1cbd5b8e7a9a4b8b2f0bd3d9a1f0c6e21"/>
+    <title>Updated the upstream module docs.</title>
+    <updated>2025-01-21T14:12:02Z</updated>
+    <media:thumbnail height="30" width="30" url="https://avatars.githubusercontent.com/u/1?s=30&amp;v=4"/>
+    <author>
+      <name>jdoe</name>
+      <uri>https://github.com/jdoe</uri>
+    </author>
+    <content type="html">&lt;pre&gt;Updated the upstream module docs.&lt;/pre&gt;</content>
+  </entry>
+  <entry>
+    <id>tag:github.com,2008:Grit::Commit/0a1b2c3d4e5f60718293a4b5c6d7e8f901234567</id>
+    <link type="text/html" rel="alternate" href="https://github.com/nginx/nginx.org/commit/0a1b2c3d4e5f60718293a4b5c6d7e8f901234567"/>
+    <title>Older commit.</title>
+    <updated>2025-01-20T09:00:00Z</updated>
+  </entry>
+</feed>`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	got, err := atom.GetEntries(context.Background(), srv.URL, atom.WithHttpClient(*srv.Client()))
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+
+	latest := got[0]
+	require.Equal(t, "5f1d3bb1cbd5b8e7a9a4b8b2f0bd3d9a1f0c6e21", latest.Commit())
+	require.Equal(t, "Updated the upstream module docs.", latest.Title)
+	require.Equal(t, atom.Author{Name: "jdoe", URI: "https://github.com/jdoe"}, latest.Author)
+	require.Equal(t, time.Date(2025, 1, 21, 14, 12, 2, 0, time.UTC), latest.Updated)
+	require.Equal(t, "https://github.com/nginx/nginx.org/commit/5f1d3bb1cbd5b8e7a9a4b8b2f0bd3d9a1f0c6e21", latest.Link.Href)
+	require.Equal(t, "0a1b2c3d4e5f60718293a4b5c6d7e8f901234567", got[1].Commit())
+}
+
+func TestGetEntries_Unreachable(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Close()
+
+	_, err := atom.GetEntries(context.Background(), srv.URL, atom.WithFetch(fetch.WithRetries(0)))
+	require.ErrorContains(t, err, "unable to download", "the error is not swallowed")
+}
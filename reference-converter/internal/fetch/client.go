@@ -0,0 +1,83 @@
+package fetch
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// NewClient builds a client for mirrors of the docs that need more than the
+// defaults. It trusts the PEM certificates in caFile on top of the system
+// ones, and goes through proxy. Without a proxy, it uses HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY like the default client. Both can be empty.
+func NewClient(caFile, proxy string) (http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return http.Client{}, fmt.Errorf("invalid proxy: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile) //nolint:gosec // the user picks the file
+		if err != nil {
+			return http.Client{}, fmt.Errorf("unable to read the CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return http.Client{}, errors.New("no certificates in the CA bundle")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	return http.Client{Transport: transport}, nil
+}
+
+// headerTransport adds headers to the requests to some hosts. Unlike headers
+// of the initial request, it checks every request, so redirects to other
+// hosts never get them.
+type headerTransport struct {
+	base   http.RoundTripper // http.DefaultTransport when nil
+	header http.Header
+	hosts  []string // every host when empty
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if !t.allows(req.URL) {
+		return base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	for key, values := range t.header {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+	return base.RoundTrip(req)
+}
+
+func (t *headerTransport) allows(u *url.URL) bool {
+	if len(t.hosts) == 0 {
+		return true
+	}
+	for _, h := range t.hosts {
+		if strings.EqualFold(h, u.Host) || strings.EqualFold(h, u.Hostname()) {
+			return true
+		}
+	}
+	return false
+}
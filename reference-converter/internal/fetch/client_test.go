@@ -0,0 +1,111 @@
+package fetch_test
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/fetch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet_Headers(t *testing.T) {
+	t.Parallel()
+	// stands in for an internal mirror of nginx.org
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" || r.Header.Get("X-Team") != "docs" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("contents"))
+	}))
+	defer mirror.Close()
+
+	_, err := fetch.Get(context.Background(), mirror.URL, fetch.WithHttpClient(*mirror.Client()))
+	require.ErrorContains(t, err, "401")
+
+	require.Equal(t, "contents", get(t, mirror.URL,
+		fetch.WithHttpClient(*mirror.Client()),
+		fetch.WithBearerToken("s3cret"),
+		fetch.WithHeader("X-Team", "docs"),
+	))
+}
+
+func TestGet_HeaderHosts(t *testing.T) {
+	t.Parallel()
+	leaked := make(chan string, 2)
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			leaked <- auth
+		}
+		_, _ = w.Write([]byte("other"))
+	}))
+	defer other.Close()
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, other.URL, http.StatusFound)
+			return
+		}
+		_, _ = w.Write([]byte("contents"))
+	}))
+	defer mirror.Close()
+
+	opts := []fetch.Option{
+		fetch.WithHttpClient(*mirror.Client()),
+		fetch.WithBearerToken("s3cret"),
+		fetch.WithHeaderHosts(strings.TrimPrefix(mirror.URL, "http://")),
+	}
+	require.Equal(t, "contents", get(t, mirror.URL, opts...))
+	require.Equal(t, "other", get(t, other.URL, opts...), "not sent to other hosts")
+	require.Equal(t, "other", get(t, mirror.URL+"/redirect", opts...), "not sent after redirects to other hosts")
+	close(leaked)
+	require.Empty(t, leaked)
+}
+
+func TestNewClient_CABundle(t *testing.T) {
+	t.Parallel()
+	mirror := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("contents"))
+	}))
+	defer mirror.Close()
+
+	client, err := fetch.NewClient("", "")
+	require.NoError(t, err)
+	_, err = fetch.Get(context.Background(), mirror.URL, fetch.WithHttpClient(client), fetch.WithRetries(0))
+	require.ErrorContains(t, err, "certificate", "the test CA is not trusted by default")
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: mirror.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, ca, 0o600))
+	client, err = fetch.NewClient(caFile, "")
+	require.NoError(t, err)
+	require.Equal(t, "contents", get(t, mirror.URL, fetch.WithHttpClient(client)))
+
+	require.NoError(t, os.WriteFile(caFile, []byte("not a cert"), 0o600))
+	_, err = fetch.NewClient(caFile, "")
+	require.Error(t, err)
+}
+
+func TestNewClient_Proxy(t *testing.T) {
+	t.Parallel()
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		_, _ = w.Write([]byte("contents"))
+	}))
+	defer proxy.Close()
+
+	client, err := fetch.NewClient("", proxy.URL)
+	require.NoError(t, err)
+	require.Equal(t, "contents", get(t, "http://mirror.invalid/docs.tar.gz", fetch.WithHttpClient(client)))
+	require.Equal(t, "http://mirror.invalid/docs.tar.gz", proxied)
+}
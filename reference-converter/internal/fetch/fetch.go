@@ -0,0 +1,364 @@
+// Package fetch downloads the docs sources, optionally through an on-disk
+// cache that is revalidated with ETag and Last-Modified.
+package fetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// ErrNotCached is returned in offline mode for URLs missing from the cache.
+var ErrNotCached = errors.New("not in the cache")
+
+type config struct {
+	client   http.Client
+	cacheDir string
+	offline  bool
+	timeout  time.Duration // for each attempt, including reading the body
+	retries  int
+	backoff  time.Duration // delay before the first retry, doubling after that
+	header   http.Header
+	hosts    []string // where to send header, every host when empty
+}
+
+// Defaults for downloads without WithTimeout, WithRetries or WithBackoff.
+const (
+	DefaultTimeout = 5 * time.Minute
+	DefaultRetries = 3
+	DefaultBackoff = time.Second
+)
+
+// maxDelay caps the delay between attempts, including the ones asked by a
+// Retry-After header.
+const maxDelay = 30 * time.Second
+
+type Option = func(*config)
+
+// WithHttpClient uses the provided client instead of the default.
+func WithHttpClient(c http.Client) Option {
+	return func(o *config) { o.client = c }
+}
+
+// WithCacheDir keeps a copy of every download in dir, and only downloads
+// again when the server says the copy is stale.
+func WithCacheDir(dir string) Option {
+	return func(o *config) { o.cacheDir = dir }
+}
+
+// WithOffline uses the cached copies without any network access.
+func WithOffline(offline bool) Option {
+	return func(o *config) { o.offline = offline }
+}
+
+// WithHeader adds a header to every request, e.g. to authenticate with a
+// mirror of the docs.
+func WithHeader(key, value string) Option {
+	return func(o *config) {
+		if o.header == nil {
+			o.header = make(http.Header)
+		}
+		o.header.Add(key, value)
+	}
+}
+
+// WithBearerToken authenticates every request with token.
+func WithBearerToken(token string) Option {
+	return WithHeader("Authorization", "Bearer "+token)
+}
+
+// WithHeaderHosts only sends the headers from WithHeader and WithBearerToken
+// to the hosts, so the credentials for a mirror don't leak to other servers,
+// including through redirects. A host without a port matches any port.
+func WithHeaderHosts(hosts ...string) Option {
+	return func(o *config) { o.hosts = append(o.hosts, hosts...) }
+}
+
+// WithTimeout gives up on an attempt after d, including reading the body. Zero
+// means no timeout.
+func WithTimeout(d time.Duration) Option {
+	return func(o *config) { o.timeout = d }
+}
+
+// WithRetries retries network errors, 5xx and 429 responses up to n times.
+func WithRetries(n int) Option {
+	return func(o *config) { o.retries = n }
+}
+
+// WithBackoff waits d before the first retry, doubling the delay for each
+// retry after that, with some jitter.
+func WithBackoff(d time.Duration) Option {
+	return func(o *config) { o.backoff = d }
+}
+
+// meta is saved next to a cached copy to revalidate it.
+type meta struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// Get returns the body of url. Callers must close it.
+//
+// The body is downloaded in full before Get returns, so that failures while
+// reading it are retried too.
+func Get(ctx context.Context, url string, opts ...Option) (io.ReadCloser, error) {
+	cfg := newConfig(opts)
+	if cfg.cacheDir == "" {
+		if cfg.offline {
+			return nil, fmt.Errorf("unable to download %s offline without a cache", url)
+		}
+		res, tmp, err := download(ctx, url, cfg, meta{}, "")
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unable to download %s: %s", url, res.Status)
+		}
+		f, err := os.Open(tmp) //nolint:gosec // our own temporary file
+		if err != nil {
+			os.Remove(tmp) //nolint:errcheck,gosec // already failing
+			return nil, err
+		}
+		return &tempFile{f}, nil
+	}
+
+	path, err := cached(ctx, url, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path) //nolint:gosec // the path is from the cache dir
+}
+
+// Cached makes sure the cache has an up to date copy of url, and returns its
+// path. It needs WithCacheDir.
+func Cached(ctx context.Context, url string, opts ...Option) (string, error) {
+	cfg := newConfig(opts)
+	if cfg.cacheDir == "" {
+		return "", errors.New("no cache dir")
+	}
+	return cached(ctx, url, cfg)
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{timeout: DefaultTimeout, retries: DefaultRetries, backoff: DefaultBackoff}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	cfg.client.Timeout = cfg.timeout
+	if len(cfg.header) > 0 {
+		cfg.client.Transport = &headerTransport{base: cfg.client.Transport, header: cfg.header, hosts: cfg.hosts}
+	}
+	return cfg
+}
+
+func cached(ctx context.Context, url string, cfg *config) (string, error) {
+	sum := sha256.Sum256([]byte(url))
+	path := filepath.Join(cfg.cacheDir, hex.EncodeToString(sum[:]))
+	log := slog.With(slog.String("url", url), slog.String("cache", path))
+
+	var m meta
+	if b, err := os.ReadFile(path + ".json"); err == nil {
+		if err := json.Unmarshal(b, &m); err != nil {
+			log.WarnContext(ctx, "ignoring broken cache metadata", slog.Any("error", err))
+			m = meta{}
+		}
+	}
+	_, statErr := os.Stat(path)
+	isCached := statErr == nil && m.URL == url
+
+	if cfg.offline {
+		if !isCached {
+			return "", fmt.Errorf("unable to use %s offline: %w", url, ErrNotCached)
+		}
+		log.DebugContext(ctx, "offline, using the cached copy")
+		return path, nil
+	}
+	if !isCached {
+		m = meta{}
+	}
+
+	if err := os.MkdirAll(cfg.cacheDir, 0o750); err != nil {
+		return "", err
+	}
+	// download next to the cached copy, so an interrupted download never
+	// leaves a truncated copy behind
+	res, tmp, err := download(ctx, url, cfg, m, cfg.cacheDir)
+	if err != nil {
+		return "", err
+	}
+
+	switch res.StatusCode {
+	case http.StatusNotModified:
+		log.DebugContext(ctx, "not modified, using the cached copy")
+		return path, nil
+	case http.StatusOK:
+	default:
+		return "", fmt.Errorf("unable to download %s: %s", url, res.Status)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp) //nolint:errcheck,gosec // already failing
+		return "", err
+	}
+	m = meta{URL: url, ETag: res.Header.Get("ETag"), LastModified: res.Header.Get("Last-Modified")}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path+".json", b, 0o600); err != nil {
+		return "", err
+	}
+	log.DebugContext(ctx, "downloaded into the cache")
+	return path, nil
+}
+
+// download sends the request, conditional on the validators of a cached copy,
+// and saves the body of a 200 response into a temporary file in dir, or the
+// default directory for temporary files when dir is empty. It retries
+// transient failures, including errors reading the body, see WithRetries.
+//
+// The body of the response is closed, callers own the temporary file.
+func download(ctx context.Context, url string, cfg *config, m meta, dir string) (*http.Response, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if m.ETag != "" {
+		req.Header.Set("If-None-Match", m.ETag)
+	}
+	if m.LastModified != "" {
+		req.Header.Set("If-Modified-Since", m.LastModified)
+	}
+
+	for attempt := 0; ; attempt++ {
+		res, tmp, err := attemptDownload(req, cfg, dir)
+		if attempt >= cfg.retries || !isTransient(ctx, res, err) {
+			if err != nil {
+				return nil, "", fmt.Errorf("unable to download %s: %w", url, err)
+			}
+			return res, tmp, nil
+		}
+		if tmp != "" {
+			os.Remove(tmp) //nolint:errcheck,gosec // retrying anyway
+		}
+
+		delay := backoff(cfg.backoff, attempt)
+		log := slog.With(slog.String("url", url), slog.Int("attempt", attempt+1))
+		if err != nil {
+			log = log.With(slog.Any("error", err))
+		} else {
+			log = log.With(slog.String("status", res.Status))
+			if after, ok := retryAfter(res.Header.Get("Retry-After"), time.Now()); ok {
+				delay = min(after, maxDelay)
+			}
+		}
+		log.WarnContext(ctx, "retrying download", slog.Duration("delay", delay))
+
+		select {
+		case <-ctx.Done():
+			return nil, "", fmt.Errorf("unable to download %s: %w", url, ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
+
+// attemptDownload sends the request once, saving the body of a 200 response.
+// Failing to read the body is an error like failing to connect, and a
+// response is only returned without error.
+func attemptDownload(req *http.Request, cfg *config, dir string) (*http.Response, string, error) {
+	res, err := cfg.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close() //nolint:errcheck // nothing to do about it
+	if res.StatusCode != http.StatusOK {
+		return res, "", nil
+	}
+	tmp, err := writeTemp(dir, res.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return res, tmp, nil
+}
+
+// isTransient reports whether a request is worth retrying.
+func isTransient(ctx context.Context, res *http.Response, err error) bool {
+	if err != nil {
+		// the caller gave up, not the network
+		return ctx.Err() == nil
+	}
+	return res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
+}
+
+// backoff is the delay before a retry: exponential with jitter, so clients
+// that failed together don't retry together.
+func backoff(base time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	// double step by step, shifting by attempt overflows for many retries
+	d := base
+	for i := 0; i < attempt && d < maxDelay; i++ {
+		d *= 2
+	}
+	d = min(d, maxDelay)
+	return d/2 + rand.N(d/2+1) //nolint:gosec // no need for a secure random number
+}
+
+// retryAfter parses a Retry-After header, either a number of seconds or a
+// HTTP date.
+func retryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(header); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		return max(0, t.Sub(now)), true
+	}
+	return 0, false
+}
+
+// writeTemp writes r to a new temporary file in dir, and returns its path.
+// Nothing is left behind on errors.
+func writeTemp(dir string, r io.Reader) (string, error) {
+	tmp, err := os.CreateTemp(dir, "download-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name()) //nolint:errcheck,gosec // already failing
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// tempFile is a downloaded body, removed once closed.
+type tempFile struct {
+	*os.File
+}
+
+func (f *tempFile) Close() error {
+	err := f.File.Close()
+	if rmErr := os.Remove(f.Name()); err == nil {
+		err = rmErr
+	}
+	return err
+}
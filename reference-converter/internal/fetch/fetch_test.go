@@ -0,0 +1,222 @@
+package fetch_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/fetch"
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, url string, opts ...fetch.Option) string {
+	t.Helper()
+	body, err := fetch.Get(context.Background(), url, opts...)
+	require.NoError(t, err)
+	defer body.Close() //nolint:errcheck // test
+	b, err := io.ReadAll(body)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestGet_Cache(t *testing.T) {
+	t.Parallel()
+	testcases := map[string]struct {
+		validator   string // response header
+		conditional string // request header
+	}{
+		"etag":          {validator: "ETag", conditional: "If-None-Match"},
+		"last modified": {validator: "Last-Modified", conditional: "If-Modified-Since"},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			const value = `"v1"`
+			downloads := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get(tc.conditional) == value {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				downloads++
+				w.Header().Set(tc.validator, value)
+				_, _ = w.Write([]byte("contents"))
+			}))
+			defer srv.Close()
+			opts := []fetch.Option{fetch.WithHttpClient(*srv.Client()), fetch.WithCacheDir(t.TempDir())}
+
+			require.Equal(t, "contents", get(t, srv.URL, opts...))
+			require.Equal(t, "contents", get(t, srv.URL, opts...))
+			require.Equal(t, 1, downloads, "the second request is answered from the cache")
+		})
+	}
+}
+
+func TestGet_Offline(t *testing.T) {
+	t.Parallel()
+	online := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.True(t, online, "no requests offline")
+		_, _ = w.Write([]byte("contents"))
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	opts := []fetch.Option{fetch.WithHttpClient(*srv.Client()), fetch.WithCacheDir(dir)}
+
+	_, err := fetch.Get(context.Background(), srv.URL, append(opts, fetch.WithOffline(true))...)
+	require.ErrorIs(t, err, fetch.ErrNotCached)
+
+	require.Equal(t, "contents", get(t, srv.URL, opts...))
+	online = false
+	require.Equal(t, "contents", get(t, srv.URL, append(opts, fetch.WithOffline(true))...))
+
+	_, err = fetch.Get(context.Background(), srv.URL, fetch.WithOffline(true))
+	require.Error(t, err, "offline needs a cache")
+}
+
+func TestGet_BadStatus(t *testing.T) {
+	t.Parallel()
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("contents"))
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	opts := []fetch.Option{fetch.WithHttpClient(*srv.Client()), fetch.WithCacheDir(dir)}
+
+	require.Equal(t, "contents", get(t, srv.URL, opts...))
+
+	status = http.StatusNotFound
+	_, err := fetch.Get(context.Background(), srv.URL, opts...)
+	require.ErrorContains(t, err, "404")
+	_, err = fetch.Get(context.Background(), srv.URL, fetch.WithHttpClient(*srv.Client()))
+	require.ErrorContains(t, err, "404")
+
+	require.Equal(t, "contents", get(t, srv.URL, append(opts, fetch.WithOffline(true))...),
+		"errors keep the cached copy")
+}
+
+func TestGet_Retries(t *testing.T) {
+	t.Parallel()
+	testcases := map[string]struct {
+		statuses  []int // one per attempt, then 200
+		retries   int
+		wantError string
+		wantCalls int
+	}{
+		"server errors": {
+			statuses:  []int{http.StatusBadGateway, http.StatusServiceUnavailable},
+			retries:   3,
+			wantCalls: 3,
+		},
+		"rate limited": {
+			statuses:  []int{http.StatusTooManyRequests},
+			retries:   1,
+			wantCalls: 2,
+		},
+		"too many failures": {
+			statuses:  []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			retries:   2,
+			wantError: "502",
+			wantCalls: 3,
+		},
+		"client errors are not retried": {
+			statuses:  []int{http.StatusNotFound},
+			retries:   3,
+			wantError: "404",
+			wantCalls: 1,
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			calls := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if calls <= len(tc.statuses) {
+					w.WriteHeader(tc.statuses[calls-1])
+					return
+				}
+				_, _ = w.Write([]byte("contents"))
+			}))
+			defer srv.Close()
+			opts := []fetch.Option{fetch.WithHttpClient(*srv.Client()), fetch.WithRetries(tc.retries), fetch.WithBackoff(time.Millisecond)}
+
+			body, err := fetch.Get(context.Background(), srv.URL, opts...)
+			if tc.wantError != "" {
+				require.ErrorContains(t, err, tc.wantError)
+			} else {
+				require.NoError(t, err)
+				require.NoError(t, body.Close())
+			}
+			require.Equal(t, tc.wantCalls, calls)
+		})
+	}
+}
+
+func TestGet_RetriesBody(t *testing.T) {
+	t.Parallel()
+	testcases := map[string]func(t *testing.T) []fetch.Option{
+		"no cache": func(t *testing.T) []fetch.Option { return nil },
+		"cache":    func(t *testing.T) []fetch.Option { return []fetch.Option{fetch.WithCacheDir(t.TempDir())} },
+	}
+	for name, opts := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			calls := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.Header().Set("Content-Length", "8")
+				if calls == 1 {
+					// the connection drops halfway through the body
+					_, _ = w.Write([]byte("cont"))
+					return
+				}
+				_, _ = w.Write([]byte("contents"))
+			}))
+			defer srv.Close()
+
+			got := get(t, srv.URL, append(opts(t), fetch.WithHttpClient(*srv.Client()), fetch.WithBackoff(0))...)
+			require.Equal(t, "contents", got)
+			require.Equal(t, 2, calls)
+		})
+	}
+}
+
+func TestGet_RetryAfter(t *testing.T) {
+	t.Parallel()
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte("contents"))
+	}))
+	defer srv.Close()
+
+	start := time.Now()
+	require.Equal(t, "contents", get(t, srv.URL, fetch.WithHttpClient(*srv.Client()), fetch.WithBackoff(0)))
+	require.GreaterOrEqual(t, time.Since(start), time.Second, "waits as asked instead of the backoff")
+}
+
+func TestGet_Timeout(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	_, err := fetch.Get(context.Background(), srv.URL,
+		fetch.WithHttpClient(*srv.Client()), fetch.WithTimeout(10*time.Millisecond), fetch.WithRetries(1), fetch.WithBackoff(0))
+	require.ErrorContains(t, err, "Timeout")
+}
@@ -0,0 +1,63 @@
+package output
+
+import (
+	"context"
+	"io"
+
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/parse"
+)
+
+// CAPI is the nginx C API documented in the development guide, for people
+// writing their own modules.
+type CAPI struct {
+	Version string    `json:"version"`
+	Source  *Source   `json:"source,omitempty"`
+	Symbols []CSymbol `json:"symbols"`
+}
+
+// CSymbol is a function, macro or type of the nginx C API.
+type CSymbol struct {
+	Name            string   `json:"name"`
+	Kind            string   `json:"kind"`
+	Signature       string   `json:"signature,omitempty"`
+	Article         string   `json:"article"` // link of the article documenting it
+	DescriptionMd   string   `json:"description_md"`
+	DescriptionHtml string   `json:"description_html"`
+	Examples        []string `json:"examples,omitempty"`
+}
+
+// devGuide is the translationKey of the development guide, the only article
+// documenting the C API. Other articles mention C symbols in passing.
+const devGuide = "docs/dev/development_guide.html"
+
+// NewCAPI extracts the C API from the DefaultLang development guide. Only
+// WithSource applies.
+func NewCAPI(version string, articles []*parse.Article, opts ...Option) *CAPI {
+	res := CAPI{
+		Version: version,
+		Source:  newConfig(opts).source,
+		Symbols: make([]CSymbol, 0),
+	}
+	for _, a := range selectLang(articles, DefaultLang, articleLang) {
+		if translationKey(articleLang(a)) != devGuide {
+			continue
+		}
+		for _, s := range a.CSymbols() {
+			desc := parse.Prose{s.Desc}
+			res.Symbols = append(res.Symbols, CSymbol{
+				Name:            s.Name,
+				Kind:            string(s.Kind),
+				Signature:       s.Signature,
+				Article:         a.Link,
+				DescriptionMd:   desc.ToMarkdown(),
+				DescriptionHtml: desc.ToHTML(),
+				Examples:        s.Examples,
+			})
+		}
+	}
+	return &res
+}
+
+func (c *CAPI) Write(ctx context.Context, dst io.Writer) error {
+	return writeJSON(dst, c)
+}
@@ -0,0 +1,59 @@
+package output_test
+
+import (
+	"testing"
+
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/output"
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/parse"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCAPI(t *testing.T) {
+	t.Parallel()
+	withSymbols := func(symbols ...parse.CSymbol) []parse.Section {
+		return []parse.Section{{Prose: parse.Prose{{CSymbols: symbols}}}}
+	}
+	palloc := parse.CSymbol{
+		Name:      "ngx_palloc",
+		Kind:      parse.CFunction,
+		Signature: "ngx_palloc(pool, size)",
+		Desc:      parse.Paragraph{Content: "Allocates memory."},
+		Examples:  []string{"p = ngx_palloc(pool, 16);"},
+	}
+	articles := []*parse.Article{
+		{Name: "Development guide", Lang: "en", Link: "/en/docs/dev/development_guide.html", Sections: withSymbols(
+			palloc,
+			parse.CSymbol{Name: "NGX_OK", Kind: parse.CMacro, Desc: parse.Paragraph{Content: "Success."}},
+		)},
+		{Name: "Other guide", Lang: "en", Link: "/en/docs/other.html", Sections: withSymbols(
+			parse.CSymbol{Name: "ngx_http_other", Kind: parse.CFunction, Desc: parse.Paragraph{Content: "Mentioned in passing."}},
+		)},
+		{Name: "Руководство", Lang: "ru", Link: "/ru/docs/dev/development_guide.html", Sections: withSymbols(palloc)},
+		{Name: "How nginx works", Lang: "en", Link: "/en/docs/works.html"},
+	}
+
+	got := output.NewCAPI("1.0", articles, output.WithSource("nginx.org.tar.gz", "abc"))
+
+	require.Equal(t, &output.CAPI{
+		Version: "1.0",
+		Source:  &output.Source{Location: "nginx.org.tar.gz", SHA256: "abc"},
+		Symbols: []output.CSymbol{
+			{
+				Name:            "ngx_palloc",
+				Kind:            "function",
+				Signature:       "ngx_palloc(pool, size)",
+				Article:         "/en/docs/dev/development_guide.html",
+				DescriptionMd:   "Allocates memory.",
+				DescriptionHtml: "<p>Allocates memory.</p>\n",
+				Examples:        []string{"p = ngx_palloc(pool, 16);"},
+			},
+			{
+				Name:            "NGX_OK",
+				Kind:            "macro",
+				Article:         "/en/docs/dev/development_guide.html",
+				DescriptionMd:   "Success.",
+				DescriptionHtml: "<p>Success.</p>\n",
+			},
+		},
+	}, got)
+}
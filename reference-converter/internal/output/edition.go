@@ -0,0 +1,26 @@
+package output
+
+// Edition picks what to keep from the docs, based on whether it needs the
+// commercial subscription.
+type Edition string
+
+const (
+	EditionAll  Edition = "all"  // everything, the default
+	EditionOSS  Edition = "oss"  // drops commercial-only modules, directives, parameters and variables
+	EditionPlus Edition = "plus" // keeps only commercial-only modules, directives, parameters and variables
+)
+
+// Editions are all the valid editions.
+var Editions = []Edition{EditionAll, EditionOSS, EditionPlus}
+
+// keeps reports whether something belongs to the edition.
+func (e Edition) keeps(isCommercial bool) bool {
+	switch e {
+	case EditionOSS:
+		return !isCommercial
+	case EditionPlus:
+		return isCommercial
+	default:
+		return true
+	}
+}
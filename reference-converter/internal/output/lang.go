@@ -0,0 +1,130 @@
+package output
+
+import (
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/parse"
+)
+
+// DefaultLang is the language of the original docs, translations fall back to
+// it.
+const DefaultLang = "en"
+
+// translationKey identifies a page across languages by dropping the language
+// from its link, e.g. "/ru/docs/http/ngx_http_access_module.html" becomes
+// "docs/http/ngx_http_access_module.html".
+func translationKey(lang, link string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(link, "/"+lang), "/")
+}
+
+// modulePrefixes start the module names in each language, e.g. "Module
+// ngx_http_core_module" or "Модуль ngx_http_core_module".
+var modulePrefixes = map[string]string{
+	"en": "Module ",
+	"ru": "Модуль ",
+}
+
+// moduleName drops the localized "Module " from the name of m, so names match
+// across languages.
+func moduleName(m *parse.Module) string {
+	if prefix, ok := modulePrefixes[m.Lang]; ok {
+		return strings.TrimPrefix(m.Name, prefix)
+	}
+	return strings.TrimPrefix(m.Name, modulePrefixes[DefaultLang])
+}
+
+func moduleLang(m *parse.Module) (string, string)   { return m.Lang, m.Link }
+func articleLang(a *parse.Article) (string, string) { return a.Lang, a.Link }
+
+// selectLang picks the pages written in lang, in the order of the DefaultLang
+// pages. Pages without a translation fall back to DefaultLang. langOf returns
+// the language and link of a page.
+func selectLang[T any](pages []T, lang string, langOf func(T) (string, string)) []T {
+	key := func(p T) string { return translationKey(langOf(p)) }
+	is := func(p T, l string) bool { pl, _ := langOf(p); return pl == l }
+
+	if lang == DefaultLang {
+		var ret []T
+		for _, p := range pages {
+			if is(p, DefaultLang) {
+				ret = append(ret, p)
+			}
+		}
+		return ret
+	}
+
+	translated := make(map[string]T)
+	for _, p := range pages {
+		if is(p, lang) {
+			translated[key(p)] = p
+		}
+	}
+
+	var ret []T
+	for _, p := range pages {
+		if !is(p, DefaultLang) {
+			continue
+		}
+		k := key(p)
+		if t, ok := translated[k]; ok {
+			ret = append(ret, t)
+			delete(translated, k)
+		} else {
+			ret = append(ret, p)
+		}
+	}
+	// keep translations that have no original, in their own order
+	for _, p := range pages {
+		if _, ok := translated[key(p)]; ok && is(p, lang) {
+			ret = append(ret, p)
+		}
+	}
+	return ret
+}
+
+// Languages lists the languages the modules are written in, sorted.
+func Languages(modules []*parse.Module) []string {
+	var langs []string
+	for _, m := range modules {
+		if m.Lang != "" && !slices.Contains(langs, m.Lang) {
+			langs = append(langs, m.Lang)
+		}
+	}
+	slices.Sort(langs)
+	return langs
+}
+
+// Multilingual holds one Reference per language.
+type Multilingual struct {
+	Version       string                `json:"version"`
+	FormatVersion int                   `json:"format_version"`
+	CommitTime    time.Time             `json:"commit_time,omitzero"`
+	Source        *Source               `json:"source,omitempty"`
+	Languages     map[string]*Reference `json:"languages"`
+}
+
+// NewMultilingual builds a Reference for every language in modules. Options
+// apply to every language, except WithLang. Articles in other languages are
+// only included through WithArticles.
+func NewMultilingual(version string, modules []*parse.Module, opts ...Option) *Multilingual {
+	cfg := newConfig(opts)
+	res := Multilingual{
+		Version:       version,
+		FormatVersion: FormatVersion,
+		CommitTime:    cfg.commitTime,
+		Source:        cfg.source,
+		Languages:     make(map[string]*Reference),
+	}
+	for _, lang := range Languages(modules) {
+		res.Languages[lang] = New(version, modules, append(opts, WithLang(lang))...)
+	}
+	return &res
+}
+
+func (m *Multilingual) Write(ctx context.Context, dst io.Writer) error {
+	return writeJSON(dst, m)
+}
@@ -5,93 +5,476 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"strings"
+	"time"
 
 	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/parse"
 )
 
 type Directive struct {
-	Name            string   `json:"name"`
-	Default         string   `json:"default"`
-	Contexts        []string `json:"contexts"`
-	SyntaxMd        []string `json:"syntax_md"`
-	SyntaxHtml      []string `json:"syntax_html"`
-	IsBlock         bool     `json:"isBlock"`
-	DescriptionMd   string   `json:"description_md"`
-	DescriptionHtml string   `json:"description_html"`
+	Name            string         `json:"name"`
+	Default         string         `json:"default"`
+	Contexts        []string       `json:"contexts"`
+	SyntaxMd        []string       `json:"syntax_md"`
+	SyntaxHtml      []string       `json:"syntax_html"`
+	SyntaxAST       []SyntaxNode   `json:"syntax_ast"`
+	IsBlock         bool           `json:"isBlock"`
+	IsCommercial    bool           `json:"isCommercial"`
+	Args            *Args          `json:"args,omitempty"`
+	Arguments       [][]Argument   `json:"arguments,omitempty"` // for each syntax
+	DescriptionMd   string         `json:"description_md"`
+	DescriptionHtml string         `json:"description_html"`
+	AppearedIn      string         `json:"appeared_in,omitempty"`
+	ParamVersions   []ParamVersion `json:"param_versions,omitempty"`
+	Parameters      []Parameter    `json:"parameters,omitempty"`
+}
+
+// Parameter documents one of the directive's parameters.
+type Parameter struct {
+	Name            string `json:"name"`
+	Value           string `json:"value,omitempty"`
+	DescriptionMd   string `json:"description_md"`
+	DescriptionHtml string `json:"description_html"`
+	AppearedIn      string `json:"appeared_in,omitempty"`
+	IsCommercial    bool   `json:"isCommercial"`
+}
+
+// toParameters converts the parameters of a directive, which are all
+// commercial-only when the directive is.
+func toParameters(ps []parse.Parameter, isCommercial bool, cfg *config) []Parameter {
+	var ret []Parameter
+	for _, p := range ps {
+		if !availableIn(p.AppearedIn, cfg.nginxVersion) || !cfg.edition.keeps(isCommercial || p.IsCommercial) {
+			continue
+		}
+		ret = append(ret, Parameter{
+			Name:            p.Name,
+			Value:           p.Value,
+			DescriptionMd:   p.Prose.ToMarkdown(),
+			DescriptionHtml: p.Prose.ToHTML(),
+			AppearedIn:      p.AppearedIn,
+			IsCommercial:    isCommercial || p.IsCommercial,
+		})
+	}
+	return ret
+}
+
+// SyntaxNode is the grammar tree of a syntax, see parse.SyntaxNode.
+type SyntaxNode struct {
+	Kind     string       `json:"kind"`
+	Value    string       `json:"value,omitempty"`
+	Children []SyntaxNode `json:"children,omitempty"`
+}
+
+func toSyntaxNode(n parse.SyntaxNode) SyntaxNode {
+	ret := SyntaxNode{Kind: string(n.Kind), Value: n.Value}
+	for _, c := range n.Children {
+		ret.Children = append(ret.Children, toSyntaxNode(c))
+	}
+	return ret
+}
+
+func toSyntaxAST(ss parse.Syntaxes) []SyntaxNode {
+	if len(ss) == 0 {
+		return nil
+	}
+	ret := make([]SyntaxNode, 0, len(ss))
+	for _, s := range ss {
+		ret = append(ret, toSyntaxNode(s.AST))
+	}
+	return ret
+}
+
+// Args is how many arguments a directive takes.
+type Args struct {
+	Min    int      `json:"min"`
+	Max    int      `json:"max"` // -1 when there is no limit
+	IsFlag bool     `json:"isFlag"`
+	Masks  []string `json:"masks"` // NGX_CONF_* masks, e.g. NGX_CONF_TAKE12 is ["NGX_CONF_TAKE1", "NGX_CONF_TAKE2"]
+}
+
+func toArgs(ss parse.Syntaxes) *Args {
+	if len(ss) == 0 {
+		return nil
+	}
+	a := ss.Arity()
+	return &Args{Min: a.Min, Max: a.Max, IsFlag: a.IsFlag, Masks: a.Masks()}
+}
+
+// Argument lists the keywords and placeholders accepted by one argument.
+type Argument struct {
+	Keywords     []string      `json:"keywords,omitempty"`
+	Placeholders []Placeholder `json:"placeholders,omitempty"`
+	Optional     bool          `json:"optional,omitempty"`
+	Repeated     bool          `json:"repeated,omitempty"`
+}
+
+// Placeholder is a free-form value, the type is a key of Reference.ValueTypes.
+type Placeholder struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+func toPlaceholders(names []string) []Placeholder {
+	if len(names) == 0 {
+		return nil
+	}
+	ret := make([]Placeholder, 0, len(names))
+	for _, name := range names {
+		ret = append(ret, Placeholder{Name: name, Type: string(parse.ClassifyPlaceholder(name))})
+	}
+	return ret
+}
+
+// ValueType tells how to validate values of a placeholder.
+type ValueType struct {
+	Pattern     string `json:"pattern,omitempty"` // regular expression, values with variables are always valid
+	Description string `json:"description"`
+}
+
+func valueTypes() map[string]ValueType {
+	ret := make(map[string]ValueType, len(parse.ValueTypes))
+	for _, vt := range parse.ValueTypes {
+		ret[string(vt)] = ValueType{Pattern: vt.Pattern(), Description: vt.Description()}
+	}
+	return ret
+}
+
+func toArguments(ss parse.Syntaxes) [][]Argument {
+	if len(ss) == 0 {
+		return nil
+	}
+	ret := make([][]Argument, 0, len(ss))
+	for _, s := range ss {
+		args := make([]Argument, 0)
+		for _, a := range s.Arguments() {
+			args = append(args, Argument{
+				Keywords:     a.Keywords,
+				Placeholders: toPlaceholders(a.Placeholders),
+				Optional:     a.Optional,
+				Repeated:     a.Repeated,
+			})
+		}
+		ret = append(ret, args)
+	}
+	return ret
+}
+
+// ParamVersion is the NGINX version a directive parameter appeared in.
+type ParamVersion struct {
+	Name       string `json:"name"`
+	AppearedIn string `json:"appeared_in"`
+}
+
+func toParamVersions(pvs []parse.ParamVersion, unavailable map[string]bool) []ParamVersion {
+	var ret []ParamVersion
+	for _, pv := range pvs {
+		if unavailable[pv.Name] {
+			continue
+		}
+		ret = append(ret, ParamVersion{Name: pv.Name, AppearedIn: pv.AppearedIn})
+	}
+	return ret
+}
+
+// unavailableParams names the parameters of the directive that appeared after
+// the target NGINX version, or need the commercial subscription in the OSS
+// edition.
+func unavailableParams(d *parse.Directive, params []parse.Parameter, cfg *config) map[string]bool {
+	ret := make(map[string]bool)
+	for _, p := range params {
+		if !availableIn(p.AppearedIn, cfg.nginxVersion) || (cfg.edition == EditionOSS && p.IsCommercial) {
+			ret[p.Name] = true
+		}
+	}
+	for _, pv := range d.Prose.ParamVersions() {
+		if !availableIn(pv.AppearedIn, cfg.nginxVersion) {
+			ret[pv.Name] = true
+		}
+	}
+	return ret
 }
 
 type Variable struct {
 	Name            string `json:"name"`
 	DescriptionMd   string `json:"description_md"`
 	DescriptionHtml string `json:"description_html"`
+	AppearedIn      string `json:"appeared_in,omitempty"`
+	IsCommercial    bool   `json:"isCommercial"`
 }
 
 type Module struct {
-	Id         string      `json:"id"`
-	Name       string      `json:"name"`
-	Directives []Directive `json:"directives"`
-	Variables  []Variable  `json:"variables,omitempty"`
+	Id              string      `json:"id"`
+	Name            string      `json:"name"`
+	Lang            string      `json:"lang,omitempty"` // only set when falling back to another language
+	IsCommercial    bool        `json:"isCommercial"`
+	DescriptionMd   string      `json:"description_md,omitempty"`
+	DescriptionHtml string      `json:"description_html,omitempty"`
+	Examples        []Example   `json:"examples,omitempty"`
+	Sections        []Section   `json:"sections,omitempty"`
+	Directives      []Directive `json:"directives"`
+	Variables       []Variable  `json:"variables,omitempty"`
 }
 
-func toModule(m *parse.Module) Module {
+// Section keeps the layout of the module page, the directives and variables
+// are listed by name.
+type Section struct {
+	Id              string   `json:"id"`
+	Name            string   `json:"name,omitempty"`
+	DescriptionMd   string   `json:"description_md,omitempty"`
+	DescriptionHtml string   `json:"description_html,omitempty"`
+	Directives      []string `json:"directives,omitempty"`
+	Variables       []string `json:"variables,omitempty"`
+}
+
+// Example is a config snippet from the "Example Configuration" section, with
+// the prose explaining it.
+type Example struct {
+	Code            string `json:"code"`
+	DescriptionMd   string `json:"description_md,omitempty"`
+	DescriptionHtml string `json:"description_html,omitempty"`
+}
+
+// toExamples splits the example section into snippets, each described by the
+// paragraphs that follow it. Paragraphs before the first snippet describe it
+// too.
+func toExamples(prose parse.Prose) []Example {
+	var examples []Example
+	var pending parse.Prose // paragraphs without a snippet yet
+	var current parse.Prose // paragraphs describing the last snippet
+	flush := func() {
+		if n := len(examples); n > 0 {
+			examples[n-1].DescriptionMd = current.ToMarkdown()
+			if len(current) > 0 {
+				examples[n-1].DescriptionHtml = current.ToHTML()
+			}
+		}
+	}
+	for _, p := range prose {
+		if len(p.Examples) == 0 {
+			if len(examples) == 0 {
+				pending = append(pending, p)
+			} else {
+				current = append(current, p)
+			}
+			continue
+		}
+		for _, code := range p.Examples {
+			flush()
+			examples = append(examples, Example{Code: code})
+			current, pending = pending, nil
+		}
+	}
+	flush()
+	return examples
+}
+
+func toModule(m *parse.Module, cfg *config) Module {
 	module := Module{
-		Name: strings.TrimLeft(m.Name, "Module "),
-		Id:   m.Link,
+		Name:         moduleName(m),
+		Id:           m.Link,
+		IsCommercial: m.IsCommercial(),
+		Examples:     toExamples(m.Example()),
+	}
+	if summary := m.Summary(); len(summary) > 0 {
+		module.DescriptionMd = summary.ToMarkdown()
+		module.DescriptionHtml = summary.ToHTML()
 	}
 	for _, section := range m.Sections {
+		sec := Section{Id: section.ID, Name: section.Name}
+		if len(section.Prose) > 0 {
+			sec.DescriptionMd = section.Prose.ToMarkdown()
+			sec.DescriptionHtml = section.Prose.ToHTML()
+		}
 		for _, directive := range section.Directives {
+			if !availableIn(directive.AppearedIn, cfg.nginxVersion) {
+				continue
+			}
+			isCommercial := module.IsCommercial || directive.Prose.IsCommercial(parse.CommercialDirective)
+			documented := directive.Parameters()
+			params := toParameters(documented, isCommercial, cfg)
+			// keep directives with commercial-only parameters in the plus edition
+			if !cfg.edition.keeps(isCommercial) && (cfg.edition != EditionPlus || len(params) == 0) {
+				continue
+			}
+			unavailable := unavailableParams(&directive, documented, cfg)
+			syntax := directive.Syntax.Without(unavailable)
+			if len(syntax) == 0 && len(directive.Syntax) > 0 {
+				// every syntax needs a parameter that is not available
+				continue
+			}
 			module.Directives = append(module.Directives, Directive{
 				Name:            directive.Name,
 				Default:         directive.Default,
 				Contexts:        directive.Contexts,
-				SyntaxMd:        directive.Syntax.ToMarkdown(),
-				SyntaxHtml:      directive.Syntax.ToHTML(),
-				IsBlock:         directive.Syntax.IsBlock(),
+				SyntaxMd:        syntax.ToMarkdown(),
+				SyntaxHtml:      syntax.ToHTML(),
+				SyntaxAST:       toSyntaxAST(syntax),
+				IsBlock:         syntax.IsBlock(),
+				IsCommercial:    isCommercial,
+				Args:            toArgs(syntax),
+				Arguments:       toArguments(syntax),
 				DescriptionMd:   directive.Prose.ToMarkdown(),
 				DescriptionHtml: directive.Prose.ToHTML(),
+				AppearedIn:      directive.AppearedIn,
+				ParamVersions:   toParamVersions(directive.Prose.ParamVersions(), unavailable),
+				Parameters:      params,
 			})
+			sec.Directives = append(sec.Directives, directive.Name)
 		}
 		for _, variable := range section.Variables {
+			isCommercial := module.IsCommercial || variable.Prose.IsCommercial(parse.CommercialVariable)
+			if !availableIn(variable.AppearedIn, cfg.nginxVersion) || !cfg.edition.keeps(isCommercial) {
+				continue
+			}
 			module.Variables = append(module.Variables, Variable{
 				Name:            variable.Name,
 				DescriptionMd:   variable.Prose.ToMarkdown(),
 				DescriptionHtml: variable.Prose.ToHTML(),
+				AppearedIn:      variable.AppearedIn,
+				IsCommercial:    isCommercial,
 			})
+			sec.Variables = append(sec.Variables, variable.Name)
 		}
+		module.Sections = append(module.Sections, sec)
 	}
 	return module
 }
 
+// Article is a guide from the docs, e.g. "How nginx processes a request".
+type Article struct {
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	Lang        string `json:"lang,omitempty"` // only set when falling back to another language
+	ContentMd   string `json:"content_md"`
+	ContentHtml string `json:"content_html"`
+}
+
+// FormatVersion changes whenever the converter changes its output for the same
+// docs, so -if-changed regenerates references made by older converters.
+// Bump it with such changes.
+const FormatVersion = 1
+
 type Reference struct {
-	Modules []Module `json:"modules"`
-	Version string   `json:"version"`
+	Modules       []Module             `json:"modules"`
+	Articles      []Article            `json:"articles,omitempty"`
+	Version       string               `json:"version"`              // commit SHA of the docs
+	FormatVersion int                  `json:"format_version"`       // see FormatVersion
+	CommitTime    time.Time            `json:"commit_time,omitzero"` // when that commit was made
+	Source        *Source              `json:"source,omitempty"`
+	Lang          string               `json:"lang"`
+	NginxVersion  string               `json:"nginx_version,omitempty"` // only set when filtered by version
+	Edition       Edition              `json:"edition"`
+	ValueTypes    map[string]ValueType `json:"value_types"`
+}
+
+// Source records the exact input a reference was built from.
+type Source struct {
+	Location string `json:"location"` // path or URL of the archive or directory
+	SHA256   string `json:"sha256"`   // see tarball.Digest
+}
+
+type config struct {
+	source       *Source
+	commitTime   time.Time
+	articles     []*parse.Article
+	lang         string
+	nginxVersion string
+	edition      Edition
+}
+type Option = func(*config)
+
+// WithNginxVersion drops directives, parameters and variables that appeared
+// after the given NGINX version, e.g. "1.18.0".
+func WithNginxVersion(v string) Option {
+	return func(o *config) { o.nginxVersion = v }
+}
+
+// WithSource records the location and digest of the source.
+func WithSource(location, sha256 string) Option {
+	return func(o *config) { o.source = &Source{Location: location, SHA256: sha256} }
+}
+
+// WithCommitTime records when the commit of the docs was made, so consumers
+// can show "docs as of <date>".
+func WithCommitTime(t time.Time) Option {
+	return func(o *config) { o.commitTime = t }
+}
+
+// WithArticles adds the articles in the language of the reference.
+func WithArticles(articles []*parse.Article) Option {
+	return func(o *config) { o.articles = articles }
+}
+
+// WithEdition keeps only what belongs to the edition, defaults to EditionAll.
+func WithEdition(e Edition) Option {
+	return func(o *config) { o.edition = e }
+}
+
+// WithLang picks the language of the reference, defaults to DefaultLang.
+// Modules that are not translated fall back to DefaultLang.
+func WithLang(lang string) Option {
+	return func(o *config) { o.lang = lang }
 }
 
-func New(version string, modules []*parse.Module) *Reference {
+func newConfig(opts []Option) *config {
+	cfg := &config{lang: DefaultLang, edition: EditionAll}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+func New(version string, modules []*parse.Module, opts ...Option) *Reference {
+	cfg := newConfig(opts)
+
 	res := Reference{
-		Modules: make([]Module, 0, len(modules)),
-		Version: version,
+		Modules:       make([]Module, 0, len(modules)),
+		Version:       version,
+		FormatVersion: FormatVersion,
+		CommitTime:    cfg.commitTime,
+		Source:        cfg.source,
+		Lang:          cfg.lang,
+		NginxVersion:  cfg.nginxVersion,
+		Edition:       cfg.edition,
+		ValueTypes:    valueTypes(),
 	}
 
-	for _, m := range modules {
-		if m.Lang == "en" {
-			mod := toModule(m)
-			// filter modules with zero directives
-			if len(mod.Directives) > 0 {
-				res.Modules = append(res.Modules, mod)
-			}
+	for _, m := range selectLang(modules, cfg.lang, moduleLang) {
+		mod := toModule(m, cfg)
+		if m.Lang != cfg.lang {
+			mod.Lang = m.Lang
+		}
+		// filter modules with zero directives
+		if len(mod.Directives) > 0 {
+			res.Modules = append(res.Modules, mod)
+		}
+	}
+
+	for _, a := range selectLang(cfg.articles, cfg.lang, articleLang) {
+		article := Article{
+			Id:          a.Link,
+			Name:        a.Name,
+			ContentMd:   a.ToMarkdown(),
+			ContentHtml: a.ToHTML(),
 		}
+		if a.Lang != cfg.lang {
+			article.Lang = a.Lang
+		}
+		res.Articles = append(res.Articles, article)
 	}
 
 	return &res
 }
 
 func (r *Reference) Write(ctx context.Context, dst io.Writer) error {
+	return writeJSON(dst, r)
+}
+
+func writeJSON(dst io.Writer, v any) error {
 	enc := json.NewEncoder(dst)
 	enc.SetEscapeHTML(false)
 	enc.SetIndent("", "  ")
-	return enc.Encode(r)
+	return enc.Encode(v)
 }
 
 func GetVersion(ctx context.Context, r io.Reader) (string, error) {
@@ -106,3 +489,46 @@ func GetVersion(ctx context.Context, r io.Reader) (string, error) {
 	}
 	return reference.Version, nil
 }
+
+// Settings are what a reference depends on besides the docs: the converter
+// that made it, and the options it was made with.
+type Settings struct {
+	FormatVersion int
+	Lang          string // "all" for a Multilingual
+	NginxVersion  string
+	Edition       Edition
+	Articles      bool
+}
+
+// GetSettings reads the Settings of a Reference or Multilingual. The
+// FormatVersion is zero for converters before it was recorded.
+func GetSettings(ctx context.Context, r io.Reader) (Settings, error) {
+	type settings struct {
+		FormatVersion int             `json:"format_version"`
+		Lang          string          `json:"lang"`
+		NginxVersion  string          `json:"nginx_version"`
+		Edition       Edition         `json:"edition"`
+		Articles      json.RawMessage `json:"articles"`
+	}
+	var reference struct {
+		settings
+		Languages map[string]settings `json:"languages"`
+	}
+	if err := json.NewDecoder(r).Decode(&reference); err != nil {
+		return Settings{}, fmt.Errorf("unable to unmarshal json data: %w", err)
+	}
+
+	s := reference.settings
+	if reference.Languages != nil {
+		// every language is made with the same options
+		s = reference.Languages[DefaultLang]
+		s.FormatVersion, s.Lang = reference.FormatVersion, "all"
+	}
+	return Settings{
+		FormatVersion: s.FormatVersion,
+		Lang:          s.Lang,
+		NginxVersion:  s.NginxVersion,
+		Edition:       s.Edition,
+		Articles:      s.Articles != nil,
+	}, nil
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/output"
 	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/parse"
@@ -18,13 +19,19 @@ func TestNew(t *testing.T) {
 		{Name: "Module 2", Lang: "en", Sections: []parse.Section{
 			{Directives: []parse.Directive{
 				{
-					Name:     "directive 2",
-					Default:  "default 2",
-					Contexts: []string{"context 1", "context 2"},
+					Name:       "directive 2",
+					Default:    "default 2",
+					Contexts:   []string{"context 1", "context 2"},
+					AppearedIn: "1.2.3",
 					Syntax: []parse.Syntax{{
 						Content: "syntax 1",
+						AST: parse.SyntaxNode{Kind: parse.SyntaxSequence, Children: []parse.SyntaxNode{
+							{Kind: parse.SyntaxLiteral, Value: "syntax"},
+							{Kind: parse.SyntaxLiteral, Value: "1"},
+						}},
 					}, {
 						Content: "syntax 2",
+						AST:     parse.SyntaxNode{Kind: parse.SyntaxSequence},
 					}},
 					Prose: parse.Prose{
 						{Content: "Test"},
@@ -37,22 +44,41 @@ func TestNew(t *testing.T) {
 	want := &output.Reference{
 		Modules: []output.Module{
 			{
-				Name: "2",
+				Name:     "2",
+				Sections: []output.Section{{Directives: []string{"directive 2"}}},
 				Directives: []output.Directive{
 					{
-						Name:            "directive 2",
-						Default:         "default 2",
-						Contexts:        []string{"context 1", "context 2"},
-						SyntaxMd:        []string{"syntax 1", "syntax 2"},
-						SyntaxHtml:      []string{"<p>syntax 1</p>\n", "<p>syntax 2</p>\n"},
+						Name:       "directive 2",
+						Default:    "default 2",
+						Contexts:   []string{"context 1", "context 2"},
+						SyntaxMd:   []string{"syntax 1", "syntax 2"},
+						SyntaxHtml: []string{"<p>syntax 1</p>\n", "<p>syntax 2</p>\n"},
+						SyntaxAST: []output.SyntaxNode{
+							{Kind: "sequence", Children: []output.SyntaxNode{
+								{Kind: "literal", Value: "syntax"},
+								{Kind: "literal", Value: "1"},
+							}},
+							{Kind: "sequence"},
+						},
+						Args: &output.Args{Min: 0, Max: 2, Masks: []string{"NGX_CONF_NOARGS", "NGX_CONF_TAKE1", "NGX_CONF_TAKE2"}},
+						Arguments: [][]output.Argument{
+							{{Keywords: []string{"syntax"}}, {Keywords: []string{"1"}}},
+							{},
+						},
 						DescriptionMd:   "Test",
 						DescriptionHtml: "<p>Test</p>\n",
+						AppearedIn:      "1.2.3",
 					},
 				},
 			},
 		},
-		Version: "1.0",
+		Version:       "1.0",
+		FormatVersion: output.FormatVersion,
+		Lang:          "en",
+		Edition:       output.EditionAll,
 	}
+	// covered by TestNew_ValueTypes
+	want.ValueTypes = got.ValueTypes
 	require.Equal(t, want, got)
 
 }
@@ -104,3 +130,422 @@ func TestGetVersion(t *testing.T) {
 	want := "1.0"
 	require.Equal(t, want, got)
 }
+
+func TestGetSettings(t *testing.T) {
+	t.Parallel()
+	modules := []*parse.Module{
+		{Name: "Module A", Lang: "en", Link: "/en/docs/a.html", Sections: []parse.Section{{Directives: []parse.Directive{{Name: "d"}}}}},
+	}
+	articles := []*parse.Article{{Name: "A guide", Lang: "en", Link: "/en/docs/guide.html"}}
+	opts := []output.Option{
+		output.WithLang("en"),
+		output.WithNginxVersion("1.25.0"),
+		output.WithEdition(output.EditionOSS),
+		output.WithArticles(articles),
+	}
+	want := output.Settings{
+		FormatVersion: output.FormatVersion,
+		Lang:          "en",
+		NginxVersion:  "1.25.0",
+		Edition:       output.EditionOSS,
+		Articles:      true,
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, output.New("1.0", modules, opts...).Write(context.Background(), &buf))
+	got, err := output.GetSettings(context.Background(), &buf)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+
+	buf.Reset()
+	require.NoError(t, output.NewMultilingual("1.0", modules, opts...).Write(context.Background(), &buf))
+	got, err = output.GetSettings(context.Background(), &buf)
+	require.NoError(t, err)
+	want.Lang = "all"
+	require.Equal(t, want, got)
+
+	buf.Reset()
+	require.NoError(t, output.New("1.0", modules).Write(context.Background(), &buf))
+	got, err = output.GetSettings(context.Background(), &buf)
+	require.NoError(t, err)
+	require.Equal(t, output.Settings{FormatVersion: output.FormatVersion, Lang: "en", Edition: output.EditionAll}, got)
+
+	got, err = output.GetSettings(context.Background(), strings.NewReader(`{"version": "1.0"}`))
+	require.NoError(t, err)
+	require.Zero(t, got.FormatVersion, "references from before format versions")
+}
+
+func TestNew_NginxVersion(t *testing.T) {
+	t.Parallel()
+	modules := []*parse.Module{
+		{Name: "Module 1", Lang: "en", Sections: []parse.Section{
+			{Directives: []parse.Directive{{Name: "ancient"}}},
+		}},
+		{Name: "Module 2", Lang: "en", Sections: []parse.Section{
+			{Directives: []parse.Directive{{Name: "shiny", AppearedIn: "1.25.1"}}},
+		}},
+		{Name: "Module 3", Lang: "en", Sections: []parse.Section{
+			{Directives: []parse.Directive{
+				{
+					Name:       "old",
+					AppearedIn: "1.9.10",
+					Syntax: parse.Syntaxes{{Content: "[`a`] [`b`]", AST: parse.SyntaxNode{Kind: parse.SyntaxSequence, Children: []parse.SyntaxNode{
+						{Kind: parse.SyntaxOptional, Children: []parse.SyntaxNode{{Kind: parse.SyntaxLiteral, Value: "a"}}},
+						{Kind: parse.SyntaxOptional, Children: []parse.SyntaxNode{{Kind: parse.SyntaxLiteral, Value: "b"}}},
+					}}}},
+					Prose: parse.Prose{
+						{Content: "The `a` parameter (1.9.11) and the `b` parameter (1.19.0)."},
+						{Content: "The `c` parameter (1.13.0) has no tag list entry."},
+						{TagList: []parse.Tag{
+							{Keyword: "a", Desc: parse.Paragraph{Content: "A"}},
+							{Keyword: "b", Desc: parse.Paragraph{Content: "B"}},
+						}},
+					},
+				},
+				{Name: "same", AppearedIn: "1.18"},
+				{Name: "new", AppearedIn: "1.18.1"},
+			}},
+			{Variables: []parse.Variable{
+				{Name: "$old"},
+				{Name: "$new", AppearedIn: "1.21.0"},
+			}},
+		}},
+	}
+	got := output.New("1.0", modules, output.WithNginxVersion("1.18.0"))
+
+	require.Equal(t, "1.18.0", got.NginxVersion)
+	require.Len(t, got.Modules, 2, "drops modules left without directives")
+
+	mixed := got.Modules[1]
+	require.Equal(t, "3", mixed.Name)
+	require.Len(t, mixed.Directives, 2)
+	require.Equal(t, "old", mixed.Directives[0].Name)
+	require.Equal(t, []string{"[`a`]"}, mixed.Directives[0].SyntaxMd, "drops newer parameters from the syntax")
+	require.Equal(t, [][]output.Argument{{{Keywords: []string{"a"}, Optional: true}}}, mixed.Directives[0].Arguments)
+	require.Equal(t, []output.ParamVersion{{Name: "a", AppearedIn: "1.9.11"}, {Name: "c", AppearedIn: "1.13.0"}}, mixed.Directives[0].ParamVersions, "every parameter, documented or not")
+	require.Equal(t, []output.Parameter{{
+		Name:            "a",
+		DescriptionMd:   "A",
+		DescriptionHtml: "<p>A</p>\n",
+		AppearedIn:      "1.9.11",
+	}}, mixed.Directives[0].Parameters)
+	require.Equal(t, "same", mixed.Directives[1].Name)
+	require.Equal(t, []output.Variable{{Name: "$old"}}, mixed.Variables)
+}
+
+func TestIsValidVersion(t *testing.T) {
+	t.Parallel()
+	require.True(t, output.IsValidVersion("1.18.0"))
+	require.True(t, output.IsValidVersion("1.25"))
+	require.False(t, output.IsValidVersion("v1.18.0"))
+	require.False(t, output.IsValidVersion("1.18.x"))
+	require.False(t, output.IsValidVersion(""))
+}
+
+func TestNew_Lang(t *testing.T) {
+	t.Parallel()
+	directives := []parse.Section{{Directives: []parse.Directive{{Name: "d"}}}}
+	modules := []*parse.Module{
+		{Name: "Module A", Lang: "en", Link: "/en/docs/a.html", Sections: directives},
+		{Name: "Module B", Lang: "en", Link: "/en/docs/b.html", Sections: directives},
+		{Name: "Module A", Lang: "ru", Link: "/ru/docs/a.html", Sections: directives},
+		{Name: "Module C", Lang: "ru", Link: "/ru/docs/c.html", Sections: directives},
+	}
+
+	got := output.New("1.0", modules, output.WithLang("ru"))
+	require.Equal(t, "ru", got.Lang)
+	ids := make([]string, 0, len(got.Modules))
+	for _, m := range got.Modules {
+		ids = append(ids, m.Id)
+	}
+	require.Equal(t, []string{"/ru/docs/a.html", "/en/docs/b.html", "/ru/docs/c.html"}, ids)
+	require.Equal(t, "", got.Modules[0].Lang)
+	require.Equal(t, "en", got.Modules[1].Lang, "marks the fallback")
+
+	all := output.NewMultilingual("1.0", modules)
+	require.Equal(t, []string{"en", "ru"}, output.Languages(modules))
+	require.Len(t, all.Languages, 2)
+	require.Len(t, all.Languages["en"].Modules, 2)
+	require.Equal(t, got, all.Languages["ru"])
+}
+
+func TestNew_ModuleNames(t *testing.T) {
+	t.Parallel()
+	directives := []parse.Section{{Directives: []parse.Directive{{Name: "d"}}}}
+	modules := []*parse.Module{
+		{Name: "Module ngx_mail_module", Lang: "en", Link: "/en/docs/mail.html", Sections: directives},
+		{Name: "Module load_module", Lang: "en", Link: "/en/docs/load.html", Sections: directives},
+		{Name: "Модуль ngx_mail_module", Lang: "ru", Link: "/ru/docs/mail.html", Sections: directives},
+	}
+
+	en := output.New("1.0", modules)
+	require.Equal(t, "ngx_mail_module", en.Modules[0].Name)
+	require.Equal(t, "load_module", en.Modules[1].Name, "only the prefix is dropped")
+
+	ru := output.New("1.0", modules, output.WithLang("ru"))
+	require.Equal(t, en.Modules[0].Name, ru.Modules[0].Name)
+}
+
+func TestNew_ValueTypes(t *testing.T) {
+	t.Parallel()
+	modules := []*parse.Module{
+		{Name: "Module 1", Lang: "en", Sections: []parse.Section{
+			{Directives: []parse.Directive{{
+				Name: "directive 1",
+				Syntax: parse.Syntaxes{{
+					AST: parse.SyntaxNode{Kind: parse.SyntaxSequence, Children: []parse.SyntaxNode{
+						{Kind: parse.SyntaxAlternatives, Children: []parse.SyntaxNode{
+							{Kind: parse.SyntaxPlaceholder, Value: "time"},
+							{Kind: parse.SyntaxPlaceholder, Value: "max_size"},
+							{Kind: parse.SyntaxPlaceholder, Value: "whatever"},
+						}},
+					}},
+				}},
+			}}},
+		}},
+	}
+	got := output.New("1.0", modules)
+
+	require.Equal(t, []output.Placeholder{
+		{Name: "time", Type: "time"},
+		{Name: "max_size", Type: "size"},
+		{Name: "whatever", Type: "string"},
+	}, got.Modules[0].Directives[0].Arguments[0][0].Placeholders)
+
+	require.Len(t, got.ValueTypes, 7)
+	for _, p := range got.Modules[0].Directives[0].Arguments[0][0].Placeholders {
+		require.Contains(t, got.ValueTypes, p.Type)
+	}
+	require.Equal(t, parse.ValueTime.Pattern(), got.ValueTypes["time"].Pattern)
+	require.Empty(t, got.ValueTypes["string"].Pattern)
+}
+
+func TestNew_Commercial(t *testing.T) {
+	t.Parallel()
+	plus := func(scope parse.CommercialScope) parse.Prose {
+		return parse.Prose{{Content: "Plus only", Commercial: scope}}
+	}
+	modules := []*parse.Module{
+		{Name: "Module oss", Lang: "en", Sections: []parse.Section{
+			{Directives: []parse.Directive{
+				{Name: "free"},
+				{Name: "paid", Prose: plus(parse.CommercialDirective)},
+				{Name: "mentions a paid parameter", Prose: plus(parse.CommercialParameter)},
+			}},
+			{Variables: []parse.Variable{
+				{Name: "$free"},
+				{Name: "$paid", Prose: plus(parse.CommercialVariable)},
+			}},
+		}},
+		{Name: "Module plus", Lang: "en", Sections: []parse.Section{
+			{ID: "summary", Prose: plus(parse.CommercialModule)},
+			{Directives: []parse.Directive{{Name: "included"}}},
+		}},
+	}
+	got := output.New("1.0", modules)
+
+	oss, plusModule := got.Modules[0], got.Modules[1]
+	require.False(t, oss.IsCommercial)
+	require.False(t, oss.Directives[0].IsCommercial)
+	require.True(t, oss.Directives[1].IsCommercial)
+	require.False(t, oss.Directives[2].IsCommercial)
+	require.False(t, oss.Variables[0].IsCommercial)
+	require.True(t, oss.Variables[1].IsCommercial)
+
+	require.True(t, plusModule.IsCommercial)
+	require.True(t, plusModule.Directives[0].IsCommercial, "inherited from the module")
+}
+
+func TestNew_Edition(t *testing.T) {
+	t.Parallel()
+	plus := func(scope parse.CommercialScope) parse.Prose {
+		return parse.Prose{{Content: "Plus only", Commercial: scope}}
+	}
+	modules := []*parse.Module{
+		{Name: "Module free", Lang: "en", Sections: []parse.Section{
+			{Directives: []parse.Directive{
+				{Name: "free"},
+				{Name: "paid", Prose: plus(parse.CommercialDirective)},
+				{
+					Name: "mixed",
+					Syntax: parse.Syntaxes{{Content: "[`weight`] [`resolve`]", AST: parse.SyntaxNode{Kind: parse.SyntaxSequence, Children: []parse.SyntaxNode{
+						{Kind: parse.SyntaxOptional, Children: []parse.SyntaxNode{{Kind: parse.SyntaxLiteral, Value: "weight"}}},
+						{Kind: parse.SyntaxOptional, Children: []parse.SyntaxNode{{Kind: parse.SyntaxLiteral, Value: "resolve"}}},
+					}}}},
+					Prose: parse.Prose{{TagList: []parse.Tag{
+						{Keyword: "weight", Desc: parse.Paragraph{Content: "free"}},
+						{Keyword: "resolve", Desc: parse.Paragraph{Content: "paid", Commercial: parse.CommercialUnspecified}},
+					}}},
+				},
+			}},
+			{Variables: []parse.Variable{
+				{Name: "$free"},
+				{Name: "$paid", Prose: plus(parse.CommercialVariable)},
+			}},
+		}},
+		{Name: "Module paid", Lang: "en", Sections: []parse.Section{
+			{ID: "summary", Prose: plus(parse.CommercialModule)},
+			{Directives: []parse.Directive{{Name: "included"}}},
+		}},
+	}
+	type summary struct {
+		Directives map[string][]string // directive to parameters
+		Variables  []string
+	}
+	summarize := func(r *output.Reference) map[string]summary {
+		res := make(map[string]summary)
+		for _, m := range r.Modules {
+			s := summary{Directives: make(map[string][]string)}
+			for _, d := range m.Directives {
+				s.Directives[d.Name] = []string{}
+				for _, p := range d.Parameters {
+					s.Directives[d.Name] = append(s.Directives[d.Name], p.Name)
+				}
+			}
+			for _, v := range m.Variables {
+				s.Variables = append(s.Variables, v.Name)
+			}
+			res[m.Name] = s
+		}
+		return res
+	}
+
+	testcases := map[output.Edition]map[string]summary{
+		output.EditionAll: {
+			"free": {
+				Directives: map[string][]string{"free": {}, "paid": {}, "mixed": {"weight", "resolve"}},
+				Variables:  []string{"$free", "$paid"},
+			},
+			"paid": {Directives: map[string][]string{"included": {}}},
+		},
+		output.EditionOSS: {
+			"free": {
+				Directives: map[string][]string{"free": {}, "mixed": {"weight"}},
+				Variables:  []string{"$free"},
+			},
+		},
+		output.EditionPlus: {
+			"free": {
+				Directives: map[string][]string{"paid": {}, "mixed": {"resolve"}},
+				Variables:  []string{"$paid"},
+			},
+			"paid": {Directives: map[string][]string{"included": {}}},
+		},
+	}
+	for edition, want := range testcases {
+		t.Run(string(edition), func(t *testing.T) {
+			t.Parallel()
+			got := output.New("1.0", modules, output.WithEdition(edition))
+			require.Equal(t, edition, got.Edition)
+			require.Equal(t, want, summarize(got))
+		})
+	}
+
+	oss := output.New("1.0", modules, output.WithEdition(output.EditionOSS))
+	mixed := oss.Modules[0].Directives[1]
+	require.Equal(t, []string{"[`weight`]"}, mixed.SyntaxMd, "drops commercial-only parameters from the syntax")
+	require.Equal(t, [][]output.Argument{{{Keywords: []string{"weight"}, Optional: true}}}, mixed.Arguments)
+}
+
+func TestNew_ModuleProse(t *testing.T) {
+	t.Parallel()
+	modules := []*parse.Module{
+		{Name: "Module 1", Lang: "en", Sections: []parse.Section{
+			{ID: "summary", Prose: parse.Prose{{Content: "Does things."}}},
+			{ID: "example", Prose: parse.Prose{
+				{Content: "Intro."},
+				{Content: "```\na;\n```", Examples: []string{"a;"}},
+				{Content: "Explains a."},
+				{Content: "```\nb;\n```", Examples: []string{"b;"}},
+			}},
+			{ID: "directives", Directives: []parse.Directive{{Name: "a"}}},
+		}},
+	}
+	got := output.New("1.0", modules).Modules[0]
+
+	require.Equal(t, "Does things.", got.DescriptionMd)
+	require.Equal(t, "<p>Does things.</p>\n", got.DescriptionHtml)
+	require.Equal(t, []output.Example{
+		{Code: "a;", DescriptionMd: "Intro.\n\nExplains a.", DescriptionHtml: "<p>Intro.</p>\n\n<p>Explains a.</p>\n"},
+		{Code: "b;"},
+	}, got.Examples)
+}
+
+func TestNew_Sections(t *testing.T) {
+	t.Parallel()
+	modules := []*parse.Module{
+		{Name: "Module 1", Lang: "en", Sections: []parse.Section{
+			{ID: "summary", Prose: parse.Prose{{Content: "Does things."}}},
+			{ID: "directives", Name: "Directives", Directives: []parse.Directive{
+				{Name: "a"},
+				{Name: "b", AppearedIn: "1.25.0"},
+			}},
+			{ID: "variables", Name: "Embedded Variables", Variables: []parse.Variable{{Name: "$a"}}},
+		}},
+	}
+	got := output.New("1.0", modules, output.WithNginxVersion("1.24.0")).Modules[0]
+
+	require.Equal(t, []output.Section{
+		{Id: "summary", DescriptionMd: "Does things.", DescriptionHtml: "<p>Does things.</p>\n"},
+		{Id: "directives", Name: "Directives", Directives: []string{"a"}},
+		{Id: "variables", Name: "Embedded Variables", Variables: []string{"$a"}},
+	}, got.Sections)
+}
+
+func TestNew_Articles(t *testing.T) {
+	t.Parallel()
+	articles := []*parse.Article{
+		{Name: "Guide", Link: "/en/docs/guide.html", Lang: "en", Sections: []parse.Section{
+			{Name: "Intro", Prose: parse.Prose{{Content: "Hello."}}},
+		}},
+		{Name: "Руководство", Link: "/ru/docs/guide.html", Lang: "ru", Sections: []parse.Section{
+			{Name: "Введение", Prose: parse.Prose{{Content: "Привет."}}},
+		}},
+		{Name: "Other", Link: "/en/docs/other.html", Lang: "en"},
+	}
+
+	got := output.New("1.0", nil, output.WithArticles(articles), output.WithLang("ru")).Articles
+	require.Equal(t, []output.Article{
+		{
+			Id:          "/ru/docs/guide.html",
+			Name:        "Руководство",
+			ContentMd:   "## Введение\n\nПривет.",
+			ContentHtml: "<h2 id=\"введение\">Введение</h2>\n\n<p>Привет.</p>\n",
+		},
+		{Id: "/en/docs/other.html", Name: "Other", Lang: "en"},
+	}, got)
+
+	require.Empty(t, output.New("1.0", nil).Articles, "articles are opt-in")
+}
+
+func TestNew_CommitTime(t *testing.T) {
+	t.Parallel()
+	commitTime := time.Date(2025, 1, 21, 14, 12, 2, 0, time.UTC)
+
+	var buf bytes.Buffer
+	require.NoError(t, output.New("5f1d3bb", nil, output.WithCommitTime(commitTime)).Write(context.Background(), &buf))
+	require.Contains(t, buf.String(), `"version": "5f1d3bb"`)
+	require.Contains(t, buf.String(), `"commit_time": "2025-01-21T14:12:02Z"`)
+
+	buf.Reset()
+	require.NoError(t, output.New("5f1d3bb", nil).Write(context.Background(), &buf))
+	require.NotContains(t, buf.String(), "commit_time")
+
+	ml := output.NewMultilingual("5f1d3bb", []*parse.Module{{Name: "Module A", Lang: "en"}}, output.WithCommitTime(commitTime))
+	require.Equal(t, commitTime, ml.CommitTime)
+}
+
+func TestNew_Source(t *testing.T) {
+	t.Parallel()
+	const digest = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	got := output.New("1.0", nil, output.WithSource("https://example.org/docs.tar.gz", digest))
+
+	var buf bytes.Buffer
+	require.NoError(t, got.Write(context.Background(), &buf))
+	require.Contains(t, buf.String(), `"sha256": "`+digest+`"`)
+
+	require.Nil(t, output.New("1.0", nil).Source)
+
+	all := output.NewMultilingual("1.0", nil, output.WithSource("https://example.org/docs.tar.gz", digest))
+	require.Equal(t, &output.Source{Location: "https://example.org/docs.tar.gz", SHA256: digest}, all.Source, "even without any modules")
+}
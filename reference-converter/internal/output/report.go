@@ -0,0 +1,89 @@
+package output
+
+import (
+	"context"
+	"io"
+	"slices"
+
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/parse"
+)
+
+// TranslationReport lists translated modules that are behind the DefaultLang
+// original, so we know which localized docs are safe to show.
+type TranslationReport struct {
+	Version string             `json:"version"`
+	Source  *Source            `json:"source,omitempty"`
+	Modules []StaleTranslation `json:"modules"`
+}
+
+// StaleTranslation is a translated module whose revision is lower than the
+// original, or that is missing some of the original's directives.
+type StaleTranslation struct {
+	Id                string   `json:"id"`
+	Name              string   `json:"name"`
+	Lang              string   `json:"lang"`
+	Rev               int      `json:"rev"`
+	OriginalRev       int      `json:"original_rev"`
+	MissingDirectives []string `json:"missing_directives,omitempty"`
+}
+
+func directiveNames(m *parse.Module) []string {
+	var names []string
+	for _, section := range m.Sections {
+		for _, directive := range section.Directives {
+			names = append(names, directive.Name)
+		}
+	}
+	return names
+}
+
+// NewTranslationReport compares every translated module with its original.
+// Only WithSource applies.
+func NewTranslationReport(version string, modules []*parse.Module, opts ...Option) *TranslationReport {
+	originals := make(map[string]*parse.Module)
+	for _, m := range modules {
+		if m.Lang == DefaultLang {
+			originals[translationKey(moduleLang(m))] = m
+		}
+	}
+
+	res := TranslationReport{
+		Version: version,
+		Source:  newConfig(opts).source,
+		Modules: make([]StaleTranslation, 0),
+	}
+	for _, m := range modules {
+		if m.Lang == DefaultLang {
+			continue
+		}
+		orig, ok := originals[translationKey(moduleLang(m))]
+		if !ok {
+			continue
+		}
+
+		translated := directiveNames(m)
+		var missing []string
+		for _, name := range directiveNames(orig) {
+			if !slices.Contains(translated, name) {
+				missing = append(missing, name)
+			}
+		}
+
+		if m.Rev >= orig.Rev && len(missing) == 0 {
+			continue
+		}
+		res.Modules = append(res.Modules, StaleTranslation{
+			Id:                m.Link,
+			Name:              m.Name,
+			Lang:              m.Lang,
+			Rev:               m.Rev,
+			OriginalRev:       orig.Rev,
+			MissingDirectives: missing,
+		})
+	}
+	return &res
+}
+
+func (r *TranslationReport) Write(ctx context.Context, dst io.Writer) error {
+	return writeJSON(dst, r)
+}
@@ -0,0 +1,54 @@
+package output_test
+
+import (
+	"testing"
+
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/output"
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/parse"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTranslationReport(t *testing.T) {
+	t.Parallel()
+	withDirectives := func(names ...string) []parse.Section {
+		var ds []parse.Directive
+		for _, n := range names {
+			ds = append(ds, parse.Directive{Name: n})
+		}
+		return []parse.Section{{Directives: ds}}
+	}
+	modules := []*parse.Module{
+		{Name: "Module A", Lang: "en", Link: "/en/docs/a.html", Rev: 10, Sections: withDirectives("a1", "a2")},
+		{Name: "Module B", Lang: "en", Link: "/en/docs/b.html", Rev: 3, Sections: withDirectives("b1")},
+		{Name: "Module C", Lang: "en", Link: "/en/docs/c.html", Rev: 5, Sections: withDirectives("c1", "c2")},
+		{Name: "Модуль A", Lang: "ru", Link: "/ru/docs/a.html", Rev: 8, Sections: withDirectives("a1")},
+		{Name: "Модуль B", Lang: "ru", Link: "/ru/docs/b.html", Rev: 3, Sections: withDirectives("b1")},
+		{Name: "Модуль C", Lang: "ru", Link: "/ru/docs/c.html", Rev: 5, Sections: withDirectives("c1")},
+		{Name: "Модуль D", Lang: "ru", Link: "/ru/docs/d.html", Rev: 1},
+	}
+
+	got := output.NewTranslationReport("1.0", modules, output.WithSource("nginx.org.tar.gz", "abc"))
+
+	require.Equal(t, &output.TranslationReport{
+		Version: "1.0",
+		Source:  &output.Source{Location: "nginx.org.tar.gz", SHA256: "abc"},
+		Modules: []output.StaleTranslation{
+			{
+				Id:                "/ru/docs/a.html",
+				Name:              "Модуль A",
+				Lang:              "ru",
+				Rev:               8,
+				OriginalRev:       10,
+				MissingDirectives: []string{"a2"},
+			},
+			{
+				Id:                "/ru/docs/c.html",
+				Name:              "Модуль C",
+				Lang:              "ru",
+				Rev:               5,
+				OriginalRev:       5,
+				MissingDirectives: []string{"c2"},
+			},
+		},
+	}, got)
+}
@@ -0,0 +1,43 @@
+package output
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var nginxVersion = regexp.MustCompile(`^\d+(\.\d+)*$`)
+
+// IsValidVersion reports whether v looks like an NGINX version, e.g. "1.18.0".
+func IsValidVersion(v string) bool { return nginxVersion.MatchString(v) }
+
+// compareVersions compares two dotted versions numerically, returning -1, 0 or
+// +1. Missing trailing components count as zero, so "1.18" == "1.18.0".
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := range max(len(as), len(bs)) {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// availableIn reports whether something that appeared in appearedIn exists in
+// the target version. Things without a version have always been there.
+func availableIn(appearedIn, target string) bool {
+	if appearedIn == "" || target == "" {
+		return true
+	}
+	return compareVersions(appearedIn, target) <= 0
+}
@@ -0,0 +1,73 @@
+package parse
+
+import "slices"
+
+// Argument is one argument of a syntax, with the keywords and placeholders it
+// accepts.
+type Argument struct {
+	Keywords     []string // fixed values from <literal>, e.g. on or unix:
+	Placeholders []string // free-form values from <value>, e.g. size
+	Optional     bool
+	Repeated     bool
+}
+
+func (a *Argument) addKeyword(k string) {
+	if !slices.Contains(a.Keywords, k) {
+		a.Keywords = append(a.Keywords, k)
+	}
+}
+
+func (a *Argument) addPlaceholder(p string) {
+	if !slices.Contains(a.Placeholders, p) {
+		a.Placeholders = append(a.Placeholders, p)
+	}
+}
+
+// collect adds the keywords and placeholders matched by n.
+func (a *Argument) collect(n SyntaxNode, keywords bool) {
+	switch n.Kind {
+	case SyntaxLiteral:
+		if keywords {
+			a.addKeyword(n.Value)
+		}
+	case SyntaxPlaceholder:
+		a.addPlaceholder(n.Value)
+	case SyntaxConcat:
+		// only the leading literal is a keyword, e.g. `buffer=` in
+		// buffer=*size*, the rest is the value
+		for i, c := range n.Children {
+			a.collect(c, keywords && i == 0)
+		}
+	default:
+		for _, c := range n.Children {
+			a.collect(c, keywords)
+		}
+	}
+}
+
+// Arguments lists the arguments of the syntax in order. Optional or repeated
+// groups of arguments, like "[format [buffer=size]]", are flattened.
+func (s *Syntax) Arguments() []Argument {
+	var args []Argument
+	var walk func(n SyntaxNode, optional, repeated bool)
+	walk = func(n SyntaxNode, optional, repeated bool) {
+		switch n.Kind {
+		case SyntaxBlock:
+			// not an argument
+		case SyntaxSequence:
+			for _, c := range n.Children {
+				walk(c, optional, repeated)
+			}
+		case SyntaxOptional:
+			walk(n.Children[0], true, repeated)
+		case SyntaxRepeat:
+			walk(n.Children[0], optional, true)
+		default:
+			arg := Argument{Optional: optional, Repeated: repeated}
+			arg.collect(n, true)
+			args = append(args, arg)
+		}
+	}
+	walk(s.AST, false, false)
+	return args
+}
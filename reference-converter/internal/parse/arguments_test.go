@@ -0,0 +1,72 @@
+package parse_test
+
+import (
+	"testing"
+
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/parse"
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/tarball"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyntax_Arguments(t *testing.T) {
+	t.Parallel()
+	testcases := map[string]struct {
+		syntax    string
+		blockAttr bool
+		want      []parse.Argument
+	}{
+		"no args": {
+			syntax:    "",
+			blockAttr: true,
+		},
+		"flag": {
+			syntax: "<literal>on</literal> | <literal>off</literal>",
+			want:   []parse.Argument{{Keywords: []string{"on", "off"}}},
+		},
+		"keywords and placeholders": {
+			syntax: "<value>address</value> | <value>CIDR</value> | <literal>unix:</literal> | <literal>all</literal>",
+			want: []parse.Argument{{
+				Keywords:     []string{"unix:", "all"},
+				Placeholders: []string{"address", "CIDR"},
+			}},
+		},
+		"keyword with a value": {
+			syntax: "<value>path</value> [<literal>buffer</literal>=<value>size</value>]",
+			want: []parse.Argument{
+				{Placeholders: []string{"path"}},
+				{Keywords: []string{"buffer="}, Placeholders: []string{"size"}, Optional: true},
+			},
+		},
+		"value with optional parts": {
+			syntax: "<value>address</value>[:<value>port</value>]",
+			want:   []parse.Argument{{Placeholders: []string{"address", "port"}}},
+		},
+		"optional group": {
+			syntax: "<value>path</value> [<value>format</value> [<value>param</value> ...]]",
+			want: []parse.Argument{
+				{Placeholders: []string{"path"}},
+				{Placeholders: []string{"format"}, Optional: true},
+				{Placeholders: []string{"param"}, Optional: true, Repeated: true},
+			},
+		},
+		"repeated": {
+			syntax: "<value>code</value> ... [<literal>=</literal>[<value>response</value>]] <value>uri</value>",
+			want: []parse.Argument{
+				{Placeholders: []string{"code"}, Repeated: true},
+				{Keywords: []string{"="}, Placeholders: []string{"response"}, Optional: true},
+				{Placeholders: []string{"uri"}},
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			f := testModuleFile(t, withSyntax(tc.syntax, tc.blockAttr))
+			ref, err := parse.Parse([]tarball.File{f}, baseURL, upsellURL)
+			require.NoError(t, err)
+
+			got := ref.Modules[0].Sections[0].Directives[0].Syntax[0].Arguments()
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package parse
+
+import "fmt"
+
+// Unbounded is the Arity.Max of directives that take any number of arguments.
+const Unbounded = -1
+
+// Arity describes how many arguments a directive takes, mirroring the
+// NGX_CONF_TAKE* masks NGINX uses to validate configs.
+type Arity struct {
+	Min, Max int  // Max is Unbounded for repeated arguments
+	IsFlag   bool // takes exactly `on` | `off`
+	IsBlock  bool // followed by a { ... } block
+}
+
+// maxConfArgs is the most arguments NGINX has a NGX_CONF_TAKE* mask for.
+const maxConfArgs = 7
+
+// Masks returns the names of the NGINX config masks matching the arity, e.g.
+// ["NGX_CONF_BLOCK", "NGX_CONF_TAKE1", "NGX_CONF_TAKE2"] for a block directive
+// that takes one or two arguments. NGINX has no mask for more than maxConfArgs
+// arguments, so directives that need more fall back to NGX_CONF_2MORE like
+// unbounded ones.
+func (a Arity) Masks() []string {
+	var masks []string
+	if a.IsBlock {
+		masks = append(masks, "NGX_CONF_BLOCK")
+	}
+	switch {
+	case a.IsFlag:
+		masks = append(masks, "NGX_CONF_FLAG")
+	case a.Max == Unbounded && a.Min == 0:
+		masks = append(masks, "NGX_CONF_ANY")
+	case a.Max == Unbounded && a.Min == 1:
+		masks = append(masks, "NGX_CONF_1MORE")
+	case a.Max == Unbounded, a.Min > maxConfArgs:
+		masks = append(masks, "NGX_CONF_2MORE")
+	default:
+		for n := a.Min; n <= min(a.Max, maxConfArgs); n++ {
+			if n == 0 {
+				masks = append(masks, "NGX_CONF_NOARGS")
+			} else {
+				masks = append(masks, fmt.Sprintf("NGX_CONF_TAKE%d", n))
+			}
+		}
+	}
+	return masks
+}
+
+// argCount returns the minimum and maximum number of arguments matched by n.
+func (n SyntaxNode) argCount() (lo, hi int) {
+	switch n.Kind {
+	case SyntaxBlock:
+		return 0, 0
+	case SyntaxLiteral, SyntaxPlaceholder, SyntaxConcat:
+		return 1, 1
+	case SyntaxOptional:
+		_, hi := n.Children[0].argCount()
+		return 0, hi
+	case SyntaxRepeat:
+		lo, _ := n.Children[0].argCount()
+		return lo, Unbounded
+	case SyntaxAlternatives:
+		for i, c := range n.Children {
+			clo, chi := c.argCount()
+			if i == 0 || clo < lo {
+				lo = clo
+			}
+			if i == 0 || hi != Unbounded && (chi == Unbounded || chi > hi) {
+				hi = chi
+			}
+		}
+		return lo, hi
+	case SyntaxSequence:
+		for _, c := range n.Children {
+			clo, chi := c.argCount()
+			lo += clo
+			if hi != Unbounded {
+				if chi == Unbounded {
+					hi = Unbounded
+				} else {
+					hi += chi
+				}
+			}
+		}
+		return lo, hi
+	}
+	return 0, 0
+}
+
+// isFlag reports whether the syntax is exactly `on` | `off`.
+func (s *Syntax) isFlag() bool {
+	args := s.AST.Children
+	if n := len(args); n > 0 && args[n-1].Kind == SyntaxBlock {
+		args = args[:n-1]
+	}
+	if len(args) != 1 || args[0].Kind != SyntaxAlternatives || len(args[0].Children) != 2 {
+		return false
+	}
+	values := map[string]bool{}
+	for _, c := range args[0].Children {
+		if c.Kind == SyntaxLiteral {
+			values[c.Value] = true
+		}
+	}
+	return values["on"] && values["off"]
+}
+
+// Arity combines the argument counts of every syntax of a directive.
+func (ss Syntaxes) Arity() Arity {
+	a := Arity{IsBlock: ss.IsBlock(), IsFlag: len(ss) > 0}
+	for i, s := range ss {
+		lo, hi := s.AST.argCount()
+		if i == 0 || lo < a.Min {
+			a.Min = lo
+		}
+		if i == 0 || a.Max != Unbounded && (hi == Unbounded || hi > a.Max) {
+			a.Max = hi
+		}
+		a.IsFlag = a.IsFlag && s.isFlag()
+	}
+	return a
+}
@@ -0,0 +1,96 @@
+package parse_test
+
+import (
+	"testing"
+
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/parse"
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/tarball"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyntaxes_Arity(t *testing.T) {
+	t.Parallel()
+	testcases := map[string]struct {
+		syntax    []string
+		blockAttr bool
+		want      parse.Arity
+		wantMasks []string
+	}{
+		"flag": {
+			syntax:    []string{"<literal>on</literal> | <literal>off</literal>"},
+			want:      parse.Arity{Min: 1, Max: 1, IsFlag: true},
+			wantMasks: []string{"NGX_CONF_FLAG"},
+		},
+		"no args": {
+			syntax:    []string{""},
+			want:      parse.Arity{},
+			wantMasks: []string{"NGX_CONF_NOARGS"},
+		},
+		"block without args": {
+			syntax:    []string{""},
+			blockAttr: true,
+			want:      parse.Arity{IsBlock: true},
+			wantMasks: []string{"NGX_CONF_BLOCK", "NGX_CONF_NOARGS"},
+		},
+		"block with args": {
+			syntax:    []string{"[<literal>=</literal> | <literal>~</literal>] <value>uri</value>"},
+			blockAttr: true,
+			want:      parse.Arity{Min: 1, Max: 2, IsBlock: true},
+			wantMasks: []string{"NGX_CONF_BLOCK", "NGX_CONF_TAKE1", "NGX_CONF_TAKE2"},
+		},
+		"optional arg": {
+			syntax:    []string{"<value>a</value> [<literal>b</literal>=<value>c</value>]"},
+			want:      parse.Arity{Min: 1, Max: 2},
+			wantMasks: []string{"NGX_CONF_TAKE1", "NGX_CONF_TAKE2"},
+		},
+		"one or more": {
+			syntax:    []string{"<value>a</value> ..."},
+			want:      parse.Arity{Min: 1, Max: parse.Unbounded},
+			wantMasks: []string{"NGX_CONF_1MORE"},
+		},
+		"two or more": {
+			syntax:    []string{"<value>a</value> <value>b</value> ..."},
+			want:      parse.Arity{Min: 2, Max: parse.Unbounded},
+			wantMasks: []string{"NGX_CONF_2MORE"},
+		},
+		"any": {
+			syntax:    []string{"[<value>a</value> ...]"},
+			want:      parse.Arity{Min: 0, Max: parse.Unbounded},
+			wantMasks: []string{"NGX_CONF_ANY"},
+		},
+		"multiple syntaxes": {
+			syntax: []string{
+				"<literal>off</literal>",
+				"<value>a</value> <value>b</value> [<value>c</value>]",
+			},
+			want:      parse.Arity{Min: 1, Max: 3},
+			wantMasks: []string{"NGX_CONF_TAKE1", "NGX_CONF_TAKE2", "NGX_CONF_TAKE3"},
+		},
+		"more than nginx supports": {
+			syntax:    []string{"<value>a</value> <value>b</value> <value>c</value> <value>d</value> <value>e</value> <value>f</value> <value>g</value> [<value>h</value>]"},
+			want:      parse.Arity{Min: 7, Max: 8},
+			wantMasks: []string{"NGX_CONF_TAKE7"},
+		},
+		"no mask for that many": {
+			syntax:    []string{"<value>a</value> <value>b</value> <value>c</value> <value>d</value> <value>e</value> <value>f</value> <value>g</value> <value>h</value> [<value>i</value>]"},
+			want:      parse.Arity{Min: 8, Max: 9},
+			wantMasks: []string{"NGX_CONF_2MORE"},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var opts []xmlOption
+			for _, s := range tc.syntax {
+				opts = append(opts, withSyntax(s, tc.blockAttr))
+			}
+			f := testModuleFile(t, opts...)
+			ref, err := parse.Parse([]tarball.File{f}, baseURL, upsellURL)
+			require.NoError(t, err)
+
+			got := ref.Modules[0].Sections[0].Directives[0].Syntax.Arity()
+			require.Equal(t, tc.want, got)
+			require.Equal(t, tc.wantMasks, got.Masks())
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package parse_test
+
+import (
+	"testing"
+
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/parse"
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/tarball"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Articles(t *testing.T) {
+	t.Parallel()
+	article := tarball.File{
+		Name: "/xml/en/docs/guide.xml",
+		Contents: []byte(`<!DOCTYPE article SYSTEM "../../dtd/article.dtd">
+		<article name="A guide" link="/en/docs/guide.html" lang="en" rev="3">
+		<section id="intro" name="Introduction">
+		<para>Read the <link doc="../test.xml" id="test"/> docs.</para>
+		</section>
+		<section id="details" name="Details">
+		<para>Some details.</para>
+		<section id="more" name="More details">
+		<para><example>test on;</example></para>
+		</section>
+		</section>
+		</article>`),
+	}
+	broken := tarball.File{
+		Name: "/xml/en/docs/broken.xml",
+		Contents: []byte(`<!DOCTYPE article SYSTEM "../../dtd/article.dtd">
+		<article name="Broken" link="/en/docs/broken.html" lang="en">
+		<section><para><list type="unknown"/></para></section>
+		</article>`),
+	}
+	news := tarball.File{
+		Name: "/xml/en/index.xml",
+		Contents: []byte(`<!DOCTYPE article SYSTEM "../dtd/article.dtd">
+		<article name="nginx news" link="/en/index.html" lang="en">
+		<section><para>Released.</para></section>
+		</article>`),
+	}
+	module := testModuleFile(t)
+
+	ref, err := parse.Parse([]tarball.File{article, broken, news, module}, baseURL, upsellURL)
+	require.NoError(t, err, "broken articles are skipped")
+	require.Len(t, ref.Modules, 1)
+	require.Len(t, ref.Articles, 1, "only the articles under docs/")
+
+	got := ref.Articles[0]
+	require.Equal(t, "A guide", got.Name)
+	require.Equal(t, "/en/docs/guide.html", got.Link)
+	require.Equal(t, "en", got.Lang)
+	require.Equal(t, 3, got.Rev)
+	require.Equal(t, lines(
+		"## Introduction",
+		"",
+		"Read the [`test`](http://example.org/en/test.html#test) docs.",
+		"",
+		"## Details",
+		"",
+		"Some details.",
+		"",
+		"### More details",
+		"",
+		"```",
+		"test on;",
+		"```",
+	), got.ToMarkdown())
+}
+
+func TestParse_ArticleBlocks(t *testing.T) {
+	t.Parallel()
+	article := tarball.File{
+		Name: "/xml/en/docs/blocks.xml",
+		Contents: []byte(`<!DOCTYPE article SYSTEM "../../dtd/article.dtd">
+		<article name="Blocks" link="/en/docs/blocks.html" lang="en">
+		<section id="setup" name="Setup">
+		<para>Install it:</para>
+		<example>make install</example>
+		<list type="bullet">
+		<listitem>one</listitem>
+		<listitem>two</listitem>
+		</list>
+		<note>See <link doc="../test.xml" id="test"/>.</note>
+		</section>
+		</article>`),
+	}
+
+	ref, err := parse.Parse([]tarball.File{article, testModuleFile(t)}, baseURL, upsellURL)
+	require.NoError(t, err)
+	require.Len(t, ref.Articles, 1)
+	require.Equal(t, lines(
+		"## Setup",
+		"",
+		"Install it:",
+		"",
+		"```",
+		"make install",
+		"```",
+		"",
+		"- one",
+		"- two",
+		"",
+		"> See [`test`](http://example.org/en/test.html#test).",
+	), ref.Articles[0].ToMarkdown())
+}
@@ -0,0 +1,126 @@
+package parse
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// CSymbolKind is the kind of a symbol of the nginx C API.
+type CSymbolKind string
+
+const (
+	CFunction   CSymbolKind = "function"   // <c-func>ngx_palloc</c-func>
+	CMacro      CSymbolKind = "macro"      // all caps, e.g. <c-def>NGX_OK</c-def>
+	CType       CSymbolKind = "type"       // ends with _t or _s, e.g. <c-def>ngx_str_t</c-def>
+	CIdentifier CSymbolKind = "identifier" // anything else, e.g. struct fields
+)
+
+// CSymbol is a function, macro or type of the nginx C API, marked with
+// <c-func> or <c-def> in the development guide.
+type CSymbol struct {
+	Name      string
+	Kind      CSymbolKind
+	Signature string    // e.g. ngx_palloc(pool, size), when the docs spell out the arguments
+	Desc      Paragraph // the paragraph or list item mentioning the symbol
+	Examples  []string  // code of the <example>s next to, or using, the symbol
+	leading   bool      // the symbol starts Desc, e.g. "<c-func>ngx_palloc</c-func> — allocates..."
+}
+
+func newCSymbol(tag, content string) CSymbol {
+	s := CSymbol{Name: strings.TrimSpace(content)}
+	if name, _, ok := strings.Cut(s.Name, "("); ok {
+		s.Signature = s.Name
+		s.Name = strings.TrimSpace(name)
+	}
+
+	isUpper := strings.IndexFunc(s.Name, unicode.IsLower) < 0
+	switch {
+	case isUpper:
+		s.Kind = CMacro
+	case tag == "c-func":
+		s.Kind = CFunction
+	case strings.HasSuffix(s.Name, "_t") || strings.HasSuffix(s.Name, "_s"):
+		s.Kind = CType
+	default:
+		s.Kind = CIdentifier
+	}
+	return s
+}
+
+// cSymbols collects the symbols marked in nodes, described by p, along with
+// the ones from nested paragraphs and lists.
+func cSymbols(nodes []markdownNode, p Paragraph) []CSymbol {
+	var res []CSymbol
+	leading := true
+	for _, n := range nodes {
+		switch md := n.md.(type) {
+		case text:
+			if strings.TrimSpace(string(md)) == "" {
+				continue
+			}
+		case *code:
+			if n.name == "c-func" || n.name == "c-def" {
+				s := newCSymbol(n.name, md.Content)
+				s.Desc = Paragraph{Content: p.Content, Examples: p.Examples}
+				s.Examples = p.Examples
+				s.leading = leading
+				res = append(res, s)
+			}
+		case *list:
+			res = append(res, md.symbols...)
+		case *Paragraph:
+			res = append(res, md.CSymbols...)
+		}
+		leading = false
+	}
+	return res
+}
+
+// CSymbols lists the C API symbols of the article, in the order they first
+// appear. A symbol is described by the list item or paragraph it starts, or by
+// its first mention otherwise. Symbols without examples of their own get the
+// examples of their section that use them.
+func (a *Article) CSymbols() []CSymbol {
+	var res []CSymbol
+	index := make(map[string]int)
+	var walk func(sections []Section)
+	walk = func(sections []Section) {
+		for _, s := range sections {
+			var examples []string
+			for _, p := range s.Prose {
+				examples = append(examples, p.Examples...)
+			}
+			for _, p := range s.Prose {
+				for _, sym := range p.CSymbols {
+					if len(sym.Examples) == 0 {
+						sym.Examples = usingExamples(sym.Name, examples)
+					}
+					i, seen := index[sym.Name]
+					switch {
+					case !seen:
+						index[sym.Name] = len(res)
+						res = append(res, sym)
+					case sym.leading && !res[i].leading:
+						res[i] = sym
+					}
+				}
+			}
+			walk(s.Sections)
+		}
+	}
+	walk(a.Sections)
+	return res
+}
+
+// usingExamples returns the examples mentioning name as a whole word.
+func usingExamples(name string, examples []string) []string {
+	re := regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`)
+	var res []string
+	for _, e := range examples {
+		if re.MatchString(e) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
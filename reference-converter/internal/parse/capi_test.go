@@ -0,0 +1,92 @@
+package parse_test
+
+import (
+	"testing"
+
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/parse"
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/tarball"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArticle_CSymbols(t *testing.T) {
+	t.Parallel()
+	guide := tarball.File{
+		Name: "/xml/en/docs/dev/development_guide.xml",
+		Contents: []byte(`<!DOCTYPE article SYSTEM "../../../dtd/article.dtd">
+		<article name="Development guide" link="/en/docs/dev/development_guide.html" lang="en">
+		<section id="strings" name="Strings">
+		<para>
+		Strings are <c-def>ngx_str_t</c-def>, see <c-func>ngx_strcmp</c-func> below.
+		</para>
+		<para>
+		<example>
+ngx_str_t  s = ngx_string("hi");
+if (ngx_strcmp(a, b) == 0) { /* equal */ }
+		</example>
+		</para>
+		<para>
+		<list type="bullet">
+		<listitem>
+		<c-func>ngx_strcmp(s1, s2)</c-func> — compares strings.
+		</listitem>
+		<listitem>
+		<c-def>NGX_OK</c-def> — operation succeeded.
+		</listitem>
+		</list>
+		</para>
+		</section>
+		<section id="memory" name="Memory">
+		<section id="pool" name="Pool">
+		<para>
+		<c-func>ngx_palloc</c-func> allocates from a pool:
+		<example>p = ngx_palloc(pool, 16);</example>
+		</para>
+		</section>
+		</section>
+		</article>`),
+	}
+
+	ref, err := parse.Parse([]tarball.File{guide}, baseURL, upsellURL)
+	require.NoError(t, err)
+	require.Len(t, ref.Articles, 1)
+
+	got := ref.Articles[0].CSymbols()
+	type symbol struct {
+		Name      string
+		Kind      parse.CSymbolKind
+		Signature string
+		Desc      string
+		Examples  []string
+	}
+	var gotSymbols []symbol
+	for _, s := range got {
+		gotSymbols = append(gotSymbols, symbol{s.Name, s.Kind, s.Signature, s.Desc.ToTrimmedMarkdown(), s.Examples})
+	}
+	strcmp := "ngx_str_t  s = ngx_string(\"hi\");\nif (ngx_strcmp(a, b) == 0) { /* equal */ }"
+	require.Equal(t, []symbol{
+		{
+			Name:     "ngx_str_t",
+			Kind:     parse.CType,
+			Desc:     "Strings are `ngx_str_t`, see `ngx_strcmp()` below.",
+			Examples: []string{strcmp},
+		},
+		{
+			Name:      "ngx_strcmp",
+			Kind:      parse.CFunction,
+			Signature: "ngx_strcmp(s1, s2)",
+			Desc:      "`ngx_strcmp(s1, s2)` — compares strings.",
+			Examples:  []string{strcmp},
+		},
+		{
+			Name: "NGX_OK",
+			Kind: parse.CMacro,
+			Desc: "`NGX_OK` — operation succeeded.",
+		},
+		{
+			Name:     "ngx_palloc",
+			Kind:     parse.CFunction,
+			Desc:     "`ngx_palloc()` allocates from a pool:\n```\np = ngx_palloc(pool, 16);\n```",
+			Examples: []string{"p = ngx_palloc(pool, 16);"},
+		},
+	}, gotSymbols)
+}
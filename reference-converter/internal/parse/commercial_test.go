@@ -0,0 +1,127 @@
+package parse_test
+
+import (
+	"testing"
+
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/parse"
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/tarball"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProse_IsCommercial(t *testing.T) {
+	t.Parallel()
+	testcases := map[string]struct {
+		content string
+		want    bool
+	}{
+		"open source": {
+			content: `Free for everyone.`,
+		},
+		"inline": {
+			content: `This directive is available as part of our <commercial_version>commercial subscription</commercial_version>.`,
+			want:    true,
+		},
+		"note": {
+			content: `<note>This directive is available as part of our <commercial_version>commercial subscription</commercial_version>.</note>`,
+			want:    true,
+		},
+		"only a parameter": {
+			content: `<list type="tag">
+			<tag-name><literal>resolve</literal></tag-name>
+			<tag-desc>available as part of our <commercial_version>commercial subscription</commercial_version></tag-desc>
+			</list>`,
+		},
+		"a parameter in the directive prose": {
+			content: `The optional <literal>status_zone</literal> parameter (1.17.1) enables collection of DNS server statistics.
+			The parameter is available as part of our <commercial_version>commercial subscription</commercial_version>.`,
+		},
+		"additionally": {
+			content: `Additionally, as part of our <commercial_version>commercial subscription</commercial_version>,
+			starting from version 1.9.13 the signature can be set explicitly.`,
+		},
+		"prior to version": {
+			content: `<note>Prior to version 1.13.6, this directive was available only as part of our
+			<commercial_version>commercial subscription</commercial_version>.</note>`,
+		},
+		"after another sentence": {
+			content: `Sets the zone. This directive is available only as part of our
+			<commercial_version>commercial subscription</commercial_version>.`,
+			want: true,
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			f := testModuleFile(t, withContent(tc.content))
+			ref, err := parse.Parse([]tarball.File{f}, baseURL, upsellURL)
+			require.NoError(t, err)
+
+			got := ref.Modules[0].Sections[0].Directives[0].Prose.IsCommercial(parse.CommercialDirective)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestDirective_Parameters_IsCommercial(t *testing.T) {
+	t.Parallel()
+	f := testModuleFile(t,
+		withSyntax("<value>address</value> [<value>parameters</value>]", false),
+		withContent(`<list type="tag">
+		<tag-name><literal>weight</literal>=<value>number</value></tag-name>
+		<tag-desc>sets the weight</tag-desc>
+		<tag-name><literal>resolve</literal></tag-name>
+		<tag-desc><para>monitors changes</para>
+		<para>available as part of our <commercial_version>commercial subscription</commercial_version></para></tag-desc>
+		<tag-name><literal>slow_start</literal>=<value>time</value></tag-name>
+		<tag-desc><para>sets the time</para>
+		<para>This parameter is available as part of our <commercial_version>commercial subscription</commercial_version>.</para></tag-desc>
+		<tag-name><literal>max_conns</literal>=<value>number</value></tag-name>
+		<tag-desc><para>limits the connections</para>
+		<para>Prior to version 1.11.5, this parameter was available as part of our <commercial_version>commercial subscription</commercial_version>.</para></tag-desc>
+		</list>`))
+	ref, err := parse.Parse([]tarball.File{f}, baseURL, upsellURL)
+	require.NoError(t, err)
+
+	params := ref.Modules[0].Sections[0].Directives[0].Parameters()
+	require.Len(t, params, 4)
+	require.False(t, params[0].IsCommercial)
+	require.True(t, params[1].IsCommercial)
+	require.True(t, params[2].IsCommercial)
+	require.False(t, params[3].IsCommercial)
+}
+
+func TestModule_IsCommercial(t *testing.T) {
+	t.Parallel()
+	module := func(summary string) tarball.File {
+		return tarball.File{
+			Name: "/xml/en/test.xml",
+			Contents: []byte(`<!DOCTYPE module SYSTEM "../dtd/module.dtd">
+			<module link="/en/test.html" lang="en">
+			<section id="summary">` + summary + `</section>
+			<section id="directives"><directive name="test"/></section>
+			</module>`),
+		}
+	}
+
+	ref, err := parse.Parse([]tarball.File{module(`<para>Free for everyone.</para>`)}, baseURL, upsellURL)
+	require.NoError(t, err)
+	require.False(t, ref.Modules[0].IsCommercial())
+
+	ref, err = parse.Parse([]tarball.File{module(`<para><note>
+	This module is available as part of our
+	<commercial_version>commercial subscription</commercial_version>.
+	</note></para>`)}, baseURL, upsellURL)
+	require.NoError(t, err)
+	require.True(t, ref.Modules[0].IsCommercial())
+}
+
+func TestCommercialVersion_NoUpsellURL(t *testing.T) {
+	t.Parallel()
+	f := testModuleFile(t, withContent(`This directive is available as part of our <commercial_version>commercial subscription</commercial_version>.`))
+	ref, err := parse.Parse([]tarball.File{f}, baseURL, "")
+	require.NoError(t, err)
+
+	prose := ref.Modules[0].Sections[0].Directives[0].Prose
+	require.Equal(t, "This directive is available as part of our commercial subscription.", prose.ToMarkdown())
+	require.True(t, prose.IsCommercial(parse.CommercialDirective))
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/xml"
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/gomarkdown/markdown"
@@ -16,6 +17,7 @@ import (
 type Syntax struct {
 	Content string
 	IsBlock bool
+	AST     SyntaxNode // machine-readable version of Content
 }
 
 func (s *Syntax) ToMarkdown() string { return s.Content }
@@ -60,13 +62,14 @@ func (ss Syntaxes) IsBlock() bool {
 
 var whitespace = regexp.MustCompile(`\s+`)
 
-// UnmarshalXML processes the elements in-order to generate correct content,
+// decodeXML processes the elements in-order to generate correct content,
 // dropping incidental whitespace present in the source XML.
-func (s *Syntax) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
-	content, err := unmarshalMarkdownXML(d, start)
+func (s *Syntax) decodeXML(d *xml.Decoder, start xml.StartElement, ctx *parseContext) error {
+	nodes, err := decodeMarkdownNodes(d, start, ctx)
 	if err != nil {
 		return err
 	}
+	content := joinMarkdown(nodes)
 	content = whitespace.ReplaceAllString(content, " ")
 	content = strings.Trim(content, " \n")
 	attrs := newAttrs(start.Attr)
@@ -77,13 +80,18 @@ func (s *Syntax) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	*s = Syntax{
 		Content: content,
 		IsBlock: isBlock,
+		AST:     parseSyntax(nodes, isBlock),
 	}
 	return nil
 }
 
 // Paragraphs contain the markdown converted content
 type Paragraph struct {
-	Content string
+	Content    string
+	TagList    []Tag           // entries of the <list type="tag">s in the paragraph
+	Commercial CommercialScope // what it says is commercial only, see commercialScope
+	Examples   []string        // code of the <example>s in the paragraph
+	CSymbols   []CSymbol       // C API marked with <c-func> or <c-def>, including nested ones
 }
 
 func (p *Paragraph) ToMarkdown() string { return p.Content }
@@ -108,16 +116,45 @@ func (p *Paragraph) ToIndentedMarkdown(isTagList bool) string {
 	return strings.Join(indentedLines, "\n")
 }
 
-// UnmarshalXML processes the elements in-order to generate correct content
+// UnmarshalXML decodes a paragraph without a parse context, e.g. with
+// xml.Unmarshal. Links and upsells need one, they fail with errNoContext.
 func (p *Paragraph) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
-	content, err := unmarshalMarkdownXML(d, start)
+	return p.decodeXML(d, start, nil)
+}
+
+func (p *Paragraph) decodeXML(d *xml.Decoder, start xml.StartElement, ctx *parseContext) error {
+	nodes, err := decodeMarkdownNodes(d, start, ctx)
+	if err != nil {
+		return err
+	}
+	p.fromNodes(nodes)
+	return nil
+}
+
+// decodeBlock reads an element outside of a <para>, e.g. an <example> or
+// <list> directly in an article's <section>, as a paragraph of its own.
+func (p *Paragraph) decodeBlock(d *xml.Decoder, start xml.StartElement, ctx *parseContext) error {
+	node, err := decodeMarkdownNode(d, start, ctx)
 	if err != nil {
 		return err
 	}
-	*p = Paragraph{Content: content}
+	p.fromNodes([]markdownNode{node})
 	return nil
 }
 
+func (p *Paragraph) fromNodes(nodes []markdownNode) {
+	*p = Paragraph{Content: joinMarkdown(nodes), Commercial: commercialScope(nodes)}
+	for _, n := range nodes {
+		switch md := n.md.(type) {
+		case *list:
+			p.TagList = append(p.TagList, md.tags...)
+		case *example:
+			p.Examples = append(p.Examples, md.code)
+		}
+	}
+	p.CSymbols = cSymbols(nodes, *p)
+}
+
 // Prose is a collection of paragraphs
 type Prose []Paragraph
 
@@ -129,6 +166,17 @@ func (t Prose) ToMarkdown() string {
 	return strings.Join(paras, "\n\n")
 }
 
+// IsCommercial reports whether any paragraph says one of the scopes, e.g.
+// "this directive", is only available with the commercial subscription.
+func (t Prose) IsCommercial(scopes ...CommercialScope) bool {
+	for _, p := range t {
+		if slices.Contains(scopes, p.Commercial) {
+			return true
+		}
+	}
+	return false
+}
+
 func mdToHTML(md []byte) []byte {
 	extensions := parser.CommonExtensions | parser.AutoHeadingIDs | parser.NoEmptyLineBeforeBlock
 	p := parser.NewWithExtensions(extensions)
@@ -147,17 +195,49 @@ func (t Prose) ToHTML() string {
 }
 
 type Directive struct {
-	Name     string   `xml:"name,attr"`
-	Default  string   `xml:"default"`
-	Contexts []string `xml:"context"`
-	Syntax   Syntaxes `xml:"syntax"`
-	Prose    Prose    `xml:"para"`
+	Name       string
+	Default    string
+	Contexts   []string
+	Syntax     Syntaxes
+	AppearedIn string // NGINX version that added the directive
+	Prose      Prose
+}
+
+// decodeXML reads the <syntax>es and <para>s of the directive with the
+// context, and the other children as plain text.
+func (dir *Directive) decodeXML(d *xml.Decoder, start xml.StartElement, ctx *parseContext) error {
+	*dir = Directive{Name: newAttrs(start.Attr)["name"]}
+	return decodeChildren(d, func(child xml.StartElement) error {
+		var err error
+		switch child.Name.Local {
+		case "default":
+			err = d.DecodeElement(&dir.Default, &child)
+		case "context":
+			var c string
+			err = d.DecodeElement(&c, &child)
+			dir.Contexts = append(dir.Contexts, c)
+		case "appeared-in":
+			err = d.DecodeElement(&dir.AppearedIn, &child)
+		case "syntax":
+			var syntax Syntax
+			err = syntax.decodeXML(d, child, ctx)
+			dir.Syntax = append(dir.Syntax, syntax)
+		case "para":
+			var para Paragraph
+			err = para.decodeXML(d, child, ctx)
+			dir.Prose = append(dir.Prose, para)
+		default:
+			err = d.Skip()
+		}
+		return err
+	})
 }
 
 // Variable represents an NGINX variable defined by a module, e.g $binary_remote_addr.
 type Variable struct {
-	Name  string
-	Prose Prose
+	Name       string
+	Prose      Prose
+	AppearedIn string // from the first "(1.11.0)" marker in the prose
 }
 
 // unmarshalVariablesCML extracts NGINX variables from the common pattern:
@@ -170,97 +250,229 @@ type Variable struct {
 //	<tag-desc>$DOCUMENTATION</tag-desc>
 //	</list>
 //	</section>
-func unmarshalVariablesCML(d *xml.Decoder, start xml.StartElement) ([]Variable, error) {
-	var v struct {
-		ID         string `xml:"id,attr"`
-		Paragraphs []struct {
-			List struct {
-				TagNames []struct {
-					Name   string `xml:"var"`
-					Suffix string `xml:"value"`
-				} `xml:"tag-name"`
-				TagDesc []Prose `xml:"tag-desc"`
-			} `xml:"list"`
-		} `xml:"para"`
-	}
-	if err := d.DecodeElement(&v, &start); err != nil {
-		return nil, fmt.Errorf("failed to parse variables: %w", err)
-	}
+func unmarshalVariablesCML(d *xml.Decoder, ctx *parseContext) ([]Variable, error) {
 	var vs []Variable
-	for _, para := range v.Paragraphs {
-		if len(para.List.TagDesc) != len(para.List.TagNames) {
-			return nil, fmt.Errorf(
-				"invalid variables section, need to have the same number of names (%d) and descriptions (%d)",
-				len(para.List.TagNames), len(para.List.TagDesc),
-			)
+	err := decodeChildren(d, func(child xml.StartElement) error {
+		if child.Name.Local != "para" {
+			return d.Skip()
+		}
+		var para Paragraph
+		if err := para.decodeXML(d, child, ctx); err != nil {
+			return err
 		}
-		for idx, tn := range para.List.TagNames {
-			name := tn.Name
-			if tn.Suffix != "" {
-				name += strings.ToUpper(tn.Suffix)
+		for _, tag := range para.TagList {
+			name := tag.Variable
+			if tag.Value != "" {
+				name += strings.ToUpper(tag.Value)
 			}
+			prose := Prose{tag.Desc}
 			vs = append(vs, Variable{
-				Name:  name,
-				Prose: para.List.TagDesc[idx],
+				Name:       name,
+				Prose:      prose,
+				AppearedIn: firstVersion(prose.ToMarkdown()),
 			})
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse variables: %w", err)
 	}
-
 	return vs, nil
 }
 
 type Section struct {
 	ID         string
+	Name       string // title of the section
 	Directives []Directive
 	Prose      Prose
 	Variables  []Variable
+	Sections   []Section // nested sections, used by articles
 }
 
-// UnmarshalXML handles parsing sections with directives vs sections with variables.
-func (s *Section) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+// decodeXML handles parsing sections with directives vs sections with variables.
+func (s *Section) decodeXML(d *xml.Decoder, start xml.StartElement, ctx *parseContext) error {
 	attrs := newAttrs(start.Attr)
 	if attrs["id"] == "variables" {
 		// parse as a list of variables
-		vs, err := unmarshalVariablesCML(d, start)
+		vs, err := unmarshalVariablesCML(d, ctx)
 		if err != nil {
 			return fmt.Errorf("failed to unmarshall variables: %w", err)
 		}
 		*s = Section{
 			ID:        "variables",
+			Name:      attrs["name"],
 			Variables: vs,
 		}
 		return nil
 	}
 
 	// parse as a normal section
-	var sec struct {
-		ID         string      `xml:"id,attr"`
-		Directives []Directive `xml:"directive"`
-		Prose      Prose       `xml:"para"`
+	*s = Section{ID: attrs["id"], Name: attrs["name"]}
+	return decodeChildren(d, func(child xml.StartElement) error {
+		var err error
+		switch child.Name.Local {
+		case "directive":
+			var dir Directive
+			err = dir.decodeXML(d, child, ctx)
+			s.Directives = append(s.Directives, dir)
+		case "para":
+			var para Paragraph
+			err = para.decodeXML(d, child, ctx)
+			s.Prose = append(s.Prose, para)
+		case "section":
+			var sub Section
+			err = sub.decodeXML(d, child, ctx)
+			s.Sections = append(s.Sections, sub)
+		default:
+			// articles have examples, lists and notes outside of <para>s
+			var para Paragraph
+			err = para.decodeBlock(d, child, ctx)
+			s.Prose = append(s.Prose, para)
+		}
+		return err
+	})
+}
+
+// decodeSections reads the <section>s of a module or article.
+func decodeSections(d *xml.Decoder, ctx *parseContext) ([]Section, error) {
+	var sections []Section
+	err := decodeChildren(d, func(child xml.StartElement) error {
+		if child.Name.Local != "section" {
+			return d.Skip()
+		}
+		var s Section
+		err := s.decodeXML(d, child, ctx)
+		sections = append(sections, s)
+		return err
+	})
+	return sections, err
+}
+
+// toMarkdown renders the section with a heading of the given level, followed
+// by its nested sections.
+func (s *Section) toMarkdown(level int) string {
+	var parts []string
+	if s.Name != "" {
+		parts = append(parts, strings.Repeat("#", level)+" "+s.Name)
+	}
+	if len(s.Prose) > 0 {
+		parts = append(parts, s.Prose.ToMarkdown())
+	}
+	for _, sub := range s.Sections {
+		parts = append(parts, sub.toMarkdown(level+1))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+type Module struct {
+	XMLName  xml.Name
+	Name     string
+	Link     string
+	Lang     string
+	Rev      int // revision, translations lag when lower than the original
+	Sections []Section
+}
+
+func (m *Module) decodeXML(d *xml.Decoder, start xml.StartElement, ctx *parseContext) error {
+	if start.Name.Local != "module" {
+		return fmt.Errorf("expected <module>, got <%s>", start.Name.Local)
+	}
+	attrs := newAttrs(start.Attr)
+	rev, err := attrs.rev()
+	if err != nil {
+		return err
 	}
-	if err := d.DecodeElement(&sec, &start); err != nil {
+	sections, err := decodeSections(d, ctx)
+	if err != nil {
 		return err
 	}
+	*m = Module{
+		XMLName:  start.Name,
+		Name:     attrs["name"],
+		Link:     attrs["link"],
+		Lang:     attrs["lang"],
+		Rev:      rev,
+		Sections: sections,
+	}
+	return nil
+}
+
+// Summary is the introduction of the module.
+func (m *Module) Summary() Prose { return m.section("summary").Prose }
 
-	*s = Section{
-		ID:         sec.ID,
-		Directives: sec.Directives,
-		Prose:      sec.Prose,
+// Example is the "Example Configuration" section of the module.
+func (m *Module) Example() Prose { return m.section("example").Prose }
+
+func (m *Module) section(id string) Section {
+	for _, s := range m.Sections {
+		if s.ID == id {
+			return s
+		}
+	}
+	return Section{}
+}
+
+// IsCommercial reports whether the whole module is only available with the
+// commercial subscription, which is stated in its summary.
+func (m *Module) IsCommercial() bool { return m.Summary().IsCommercial(CommercialModule) }
+
+// Article is a guide, e.g. "How nginx processes a request".
+type Article struct {
+	XMLName  xml.Name
+	Name     string
+	Link     string
+	Lang     string
+	Rev      int
+	Sections []Section
+}
+
+func (a *Article) decodeXML(d *xml.Decoder, start xml.StartElement, ctx *parseContext) error {
+	if start.Name.Local != "article" {
+		return fmt.Errorf("expected <article>, got <%s>", start.Name.Local)
+	}
+	attrs := newAttrs(start.Attr)
+	rev, err := attrs.rev()
+	if err != nil {
+		return err
+	}
+	sections, err := decodeSections(d, ctx)
+	if err != nil {
+		return err
+	}
+	*a = Article{
+		XMLName:  start.Name,
+		Name:     attrs["name"],
+		Link:     attrs["link"],
+		Lang:     attrs["lang"],
+		Rev:      rev,
+		Sections: sections,
 	}
 	return nil
 }
 
-type Module struct {
-	XMLName  xml.Name  `xml:"module"`
-	Name     string    `xml:"name,attr"`
-	Link     string    `xml:"link,attr"`
-	Lang     string    `xml:"lang,attr"`
-	Sections []Section `xml:"section"`
+// ToMarkdown renders the sections of the article, the article name is left to
+// the caller.
+func (a *Article) ToMarkdown() string {
+	parts := make([]string, 0, len(a.Sections))
+	for _, s := range a.Sections {
+		parts = append(parts, s.toMarkdown(2))
+	}
+	return strings.Join(parts, "\n\n")
 }
 
+func (a *Article) ToHTML() string { return string(mdToHTML([]byte(a.ToMarkdown()))) }
+
 // page represents <article>s or <module>s that are used with <link>
 type page struct {
-	Name string `xml:"name,attr"`
-	Link string `xml:"link,attr"`
+	Name string
+	Link string
 	path string // Path to the xml file
 }
+
+// decodeXML keeps the name and link of the page, only the root element
+// matters.
+func (p *page) decodeXML(d *xml.Decoder, start xml.StartElement, ctx *parseContext) error {
+	attrs := newAttrs(start.Attr)
+	p.Name, p.Link = attrs["name"], attrs["link"]
+	return d.Skip()
+}
@@ -13,18 +13,21 @@ type link struct {
 
 func (l *link) ToMarkdown() string { return l.content }
 
-// UnmarshalXML processes the elements in-order to generate correct content
-func (l *link) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+// decodeXML processes the elements in-order to generate correct content
+func (l *link) decodeXML(d *xml.Decoder, start xml.StartElement, ctx *parseContext) error {
+	if ctx == nil {
+		return errNoContext
+	}
 	// handle inner content like <link>title</link> or
 	// <link><literal>title</literal></link>
-	title, err := unmarshalMarkdownXML(d, start)
+	title, err := unmarshalMarkdownXML(d, start, ctx)
 	if err != nil {
 		return err
 	}
 
 	// manually work with attrs, unmarshalMarkdownXML consumes the whole element
 	attrs := newAttrs(start.Attr)
-	p, hasPage := current.getPage(attrs["doc"])
+	p, hasPage := ctx.getPage(attrs["doc"])
 
 	// linking to a directive, e.g. <link id="anchor" />
 	if title == "" && attrs["id"] != "" {
@@ -40,7 +43,7 @@ func (l *link) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	href := attrs["url"]
 	if href == "" {
 		// default to self-link, e.g. <link id="anchor">
-		href = current.currentPage.Link
+		href = ctx.page.Link
 		if hasPage {
 			// linking to another page, e.g. <link doc="page.xml">
 			href = p.Link
@@ -49,7 +52,7 @@ func (l *link) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 		if attrs["id"] != "" {
 			href += "#" + attrs["id"]
 		}
-		href = current.baseURL + href
+		href = ctx.ref.baseURL + href
 	}
 
 	*l = link{
@@ -1,6 +1,7 @@
 package parse_test
 
 import (
+	"encoding/xml"
 	"testing"
 
 	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/parse"
@@ -72,3 +73,13 @@ func TestLink_ToMarkdown(t *testing.T) {
 		})
 	}
 }
+
+func TestLink_NoContext(t *testing.T) {
+	t.Parallel()
+	var p parse.Paragraph
+	err := xml.Unmarshal([]byte(`<para>See <link id="listen"/>.</para>`), &p)
+	require.ErrorContains(t, err, "no parse context", "links need the base URL of a Parse")
+
+	require.NoError(t, xml.Unmarshal([]byte(`<para>See <literal>listen</literal>.</para>`), &p))
+	require.Equal(t, "See `listen`.", p.ToMarkdown())
+}
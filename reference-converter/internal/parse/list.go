@@ -8,7 +8,7 @@ import (
 
 // unorderedList handles <list type="bullet">.
 type unorderedList struct {
-	Items []Paragraph `xml:"listitem"`
+	Items []Paragraph
 }
 
 func (t *unorderedList) ToMarkdown() string {
@@ -21,7 +21,7 @@ func (t *unorderedList) ToMarkdown() string {
 
 // orderedList handles <list type="enum">.
 type orderedList struct {
-	Items []Paragraph `xml:"listitem"`
+	Items []Paragraph
 }
 
 func (t *orderedList) ToMarkdown() string {
@@ -32,12 +32,71 @@ func (t *orderedList) ToMarkdown() string {
 	return sb.String()
 }
 
+// Tag is an entry of a <list type="tag">, which directives use to document
+// their parameters, e.g.
+//
+//	<tag-name id="backlog"><literal>backlog</literal>=<value>number</value></tag-name>
+//	<tag-desc>sets the backlog parameter...</tag-desc>
+type Tag struct {
+	ID       string // from the id attribute
+	Keyword  string // first <literal> of the name, e.g. backlog
+	Value    string // first <value> of the name, e.g. number
+	Variable string // first <var> of the name, e.g. $binary_remote_addr
+	Desc     Paragraph
+}
+
+// tagName is a <tag-name>, keeping the keyword and value apart from the
+// markdown.
+type tagName struct {
+	Paragraph
+	id, keyword, value, variable string
+}
+
+// decodeXML processes the elements in-order to generate correct content
+func (t *tagName) decodeXML(d *xml.Decoder, start xml.StartElement, ctx *parseContext) error {
+	nodes, err := decodeMarkdownNodes(d, start, ctx)
+	if err != nil {
+		return err
+	}
+	*t = tagName{
+		Paragraph: Paragraph{Content: joinMarkdown(nodes)},
+		id:        newAttrs(start.Attr)["id"],
+	}
+	for _, n := range nodes {
+		c, ok := n.md.(*code)
+		switch {
+		case !ok:
+		case n.name == "literal" && t.keyword == "":
+			t.keyword = strings.TrimSuffix(c.Content, "=")
+		case n.name == "value" && t.value == "":
+			t.value = c.Content
+		case n.name == "var" && t.variable == "":
+			t.variable = c.Content
+		}
+	}
+	return nil
+}
+
 // taglist handles <list type="tag">. These are rendered as <dl>s in the
 // official docs, which don't have a direct mapping in pure markdown. Simulates
 // it using unordered lists and indentation.
 type taglist struct {
-	TagNames []Paragraph `xml:"tag-name"`
-	TagDesc  []Paragraph `xml:"tag-desc"`
+	TagNames []tagName
+	TagDesc  []Paragraph
+}
+
+func (t *taglist) tags() []Tag {
+	tags := make([]Tag, 0, len(t.TagNames))
+	for i, name := range t.TagNames {
+		tags = append(tags, Tag{
+			ID:       name.id,
+			Keyword:  name.keyword,
+			Value:    name.value,
+			Variable: name.variable,
+			Desc:     t.TagDesc[i],
+		})
+	}
+	return tags
 }
 
 func (t *taglist) ToMarkdown() string {
@@ -59,32 +118,64 @@ func (t *taglist) ToMarkdown() string {
 // list parses a variety of `<list>` types to markdown.
 type list struct {
 	content string
+	tags    []Tag     // only for <list type="tag">
+	symbols []CSymbol // C API marked in the items
 }
 
 func (t *list) ToMarkdown() string { return t.content }
 
-// UnmarshalXML processes the elements in-order to generate correct content
-func (l *list) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+// decodeXML processes the elements in-order to generate correct content
+func (l *list) decodeXML(d *xml.Decoder, start xml.StartElement, ctx *parseContext) error {
 	attrs := newAttrs(start.Attr)
 	listType := attrs["type"]
+	if listType != "bullet" && listType != "tag" && listType != "enum" {
+		return fmt.Errorf("unknown list type '%s'", listType)
+	}
+
+	var names []tagName
+	var items []Paragraph // <listitem>s, or <tag-desc>s of tag lists
+	err := decodeChildren(d, func(child xml.StartElement) error {
+		switch {
+		case listType == "tag" && child.Name.Local == "tag-name":
+			var name tagName
+			err := name.decodeXML(d, child, ctx)
+			names = append(names, name)
+			return err
+		case listType == "tag" && child.Name.Local == "tag-desc",
+			listType != "tag" && child.Name.Local == "listitem":
+			var item Paragraph
+			err := item.decodeXML(d, child, ctx)
+			items = append(items, item)
+			return err
+		default:
+			return d.Skip()
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to parse %s list: %w", listType, err)
+	}
+	if listType == "tag" && len(names) != len(items) {
+		return fmt.Errorf("tag lists must have the same number of names (%d) and descriptions (%d)", len(names), len(items))
+	}
+
 	var sub markdowner
 	switch listType {
 	case "bullet":
-		sub = &unorderedList{}
+		sub = &unorderedList{Items: items}
 	case "tag":
-		sub = &taglist{}
+		sub = &taglist{TagNames: names, TagDesc: items}
 	case "enum":
-		sub = &orderedList{}
-	default:
-		return fmt.Errorf("unknown list type '%s'", listType)
-	}
-
-	if err := d.DecodeElement(sub, &start); err != nil {
-		return fmt.Errorf("failed to parse %s list: %w", listType, err)
+		sub = &orderedList{Items: items}
 	}
 
 	*l = list{
 		content: sub.ToMarkdown(),
 	}
+	if tl, ok := sub.(*taglist); ok {
+		l.tags = tl.tags()
+	}
+	for _, item := range items {
+		l.symbols = append(l.symbols, item.CSymbols...)
+	}
 	return nil
 }
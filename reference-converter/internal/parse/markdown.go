@@ -6,15 +6,45 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"regexp"
 	"strings"
 )
 
 // unmarshalMarkdownXML reads the XML in-order and converts it to markdown.
 //
-// Use it from xml.Unmashaler implementations for elements that need to convert
-// their inner XML to markdown.
-func unmarshalMarkdownXML(d *xml.Decoder, parent xml.StartElement) (string, error) {
-	var content strings.Builder
+// Use it from elements that need to convert their inner XML to markdown.
+func unmarshalMarkdownXML(d *xml.Decoder, parent xml.StartElement, ctx *parseContext) (string, error) {
+	nodes, err := decodeMarkdownNodes(d, parent, ctx)
+	if err != nil {
+		return "", err
+	}
+	return joinMarkdown(nodes), nil
+}
+
+// markdownNode is a child of an element being converted to markdown. The name
+// is the XML element name, or empty for inline text.
+type markdownNode struct {
+	name string
+	md   markdowner
+}
+
+// text is inline text between XML elements.
+type text string
+
+func (t text) ToMarkdown() string { return string(t) }
+
+// contextual elements need the parse context, e.g. to render links.
+// decodeMarkdownNodes hands its context down to them, rather than decoding
+// them with xml.Decoder.DecodeElement.
+type contextual interface {
+	decodeXML(d *xml.Decoder, start xml.StartElement, ctx *parseContext) error
+}
+
+// decodeMarkdownNodes reads the XML in-order, converting each child to a
+// markdowner. Use it instead of unmarshalMarkdownXML when the structure of the
+// children matters.
+func decodeMarkdownNodes(d *xml.Decoder, parent xml.StartElement, ctx *parseContext) ([]markdownNode, error) {
+	var nodes []markdownNode
 LOOP:
 	for {
 		token, err := d.Token()
@@ -22,32 +52,103 @@ LOOP:
 			break
 		}
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 
 		switch t := token.(type) {
 		case xml.CharData: // consume inline text
-			content.WriteString(strings.Trim(string(t), "\t"))
+			nodes = append(nodes, markdownNode{md: text(strings.Trim(string(t), "\t"))})
 		case xml.StartElement:
-			md := chooseMarkdowner(t.Name)
-
-			// consume child element
-			if err := d.DecodeElement(md, &t); err != nil {
-				return "", fmt.Errorf("failed to decode <%s>: %w", t.Name.Local, err)
+			node, err := decodeMarkdownNode(d, t, ctx)
+			if err != nil {
+				return nil, err
 			}
-			content.WriteString(md.ToMarkdown())
+			nodes = append(nodes, node)
 
 		case xml.EndElement:
 			if t.Name.Local != parent.Name.Local {
-				return "", fmt.Errorf("unexpected </%s>, wanted </%s>", t.Name.Local, parent.Name.Local)
+				return nil, fmt.Errorf("unexpected </%s>, wanted </%s>", t.Name.Local, parent.Name.Local)
 			}
 			break LOOP
 		case xml.Comment, xml.ProcInst, xml.Directive:
 			// no processing needed
 		}
 	}
+	return nodes, nil
+}
 
-	return strings.TrimSuffix(content.String(), "\n "), nil
+// decodeMarkdownNode consumes the element starting with start, converting it
+// to a markdowner.
+func decodeMarkdownNode(d *xml.Decoder, start xml.StartElement, ctx *parseContext) (markdownNode, error) {
+	md := chooseMarkdowner(start.Name)
+	var err error
+	if c, ok := md.(contextual); ok {
+		err = c.decodeXML(d, start, ctx)
+	} else {
+		err = d.DecodeElement(md, &start)
+	}
+	if err != nil {
+		return markdownNode{}, fmt.Errorf("failed to decode <%s>: %w", start.Name.Local, err)
+	}
+	return markdownNode{name: start.Name.Local, md: md}, nil
+}
+
+// CommercialScope is what a paragraph says is only available as part of the
+// commercial subscription.
+type CommercialScope string
+
+const (
+	CommercialNone        CommercialScope = ""
+	CommercialModule      CommercialScope = "module"      // "This module is available as part of our commercial subscription."
+	CommercialDirective   CommercialScope = "directive"   // "This directive is available as part of our commercial subscription."
+	CommercialParameter   CommercialScope = "parameter"   // "This parameter is available as part of our commercial subscription."
+	CommercialVariable    CommercialScope = "variable"    // "This variable is available as part of our commercial subscription."
+	CommercialUnspecified CommercialScope = "unspecified" // "Available as part of our commercial subscription.", e.g. in a parameter description
+)
+
+// commercialStatement matches the text leading to a <commercial_version> that
+// states something is only available with the commercial subscription. Other
+// mentions are not statements about the element they are in, e.g. "Prior to
+// version 1.27.3, this directive was available only as part of our…",
+// "Additionally, as part of our…" or "The parameter is available as part of
+// our…" in the prose of a directive.
+var commercialStatement = regexp.MustCompile(`(?i)(?:^|[.:!?]\s+)(?:this\s+(module|directive|parameter|variable)\s+is\s+)?available\s+(?:only\s+)?as\s+part\s+of\s+our\s*$`)
+
+// commercialScope returns what the nodes state is only available with the
+// commercial subscription, directly or inside a <note> or <para>. Nested lists
+// are not included, they usually describe parameters.
+func commercialScope(nodes []markdownNode) CommercialScope {
+	for i, n := range nodes {
+		switch md := n.md.(type) {
+		case *commercialVersion:
+			m := commercialStatement.FindStringSubmatch(strings.TrimSpace(joinMarkdown(nodes[:i])))
+			switch {
+			case m == nil:
+			case m[1] == "":
+				return CommercialUnspecified
+			default:
+				return CommercialScope(strings.ToLower(m[1]))
+			}
+		case *note:
+			if md.commercial != CommercialNone {
+				return md.commercial
+			}
+		case *Paragraph:
+			if md.Commercial != CommercialNone {
+				return md.Commercial
+			}
+		}
+	}
+	return CommercialNone
+}
+
+// joinMarkdown concatenates the markdown of all the nodes.
+func joinMarkdown(nodes []markdownNode) string {
+	var content strings.Builder
+	for _, n := range nodes {
+		content.WriteString(n.md.ToMarkdown())
+	}
+	return strings.TrimSuffix(content.String(), "\n ")
 }
 
 type markdowner interface {
@@ -103,7 +204,8 @@ type code struct {
 
 func (t *code) ToMarkdown() string {
 	s := t.Content
-	if t.suffix != "" {
+	// <c-func>s sometimes spell out the arguments, e.g. ngx_palloc(pool, size)
+	if t.suffix != "" && !strings.HasSuffix(s, ")") {
 		s += t.suffix
 	}
 	s = fmt.Sprintf("`%s`", s)
@@ -117,19 +219,20 @@ func (t *code) ToMarkdown() string {
 // <example> elements shows snippets of config, C code, etc.
 type example struct {
 	content string
+	code    string // content without the fence
 }
 
 func (e *example) ToMarkdown() string { return e.content }
 
-// UnmarshalXML processes the elements in-order to generate correct content.
+// decodeXML processes the elements in-order to generate correct content.
 // Some <example>s contain <emphasis>, so needs to be parsed in-order.
-func (e *example) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
-	content, err := unmarshalMarkdownXML(d, start)
+func (e *example) decodeXML(d *xml.Decoder, start xml.StartElement, ctx *parseContext) error {
+	content, err := unmarshalMarkdownXML(d, start, ctx)
 	if err != nil {
 		return err
 	}
 	content = strings.Trim(content, "\n")
-	*e = example{content: fmt.Sprintf("```\n%s\n```", content)}
+	*e = example{content: fmt.Sprintf("```\n%s\n```", content), code: content}
 	return nil
 }
 
@@ -160,28 +263,50 @@ func (h *httpStatus) ToMarkdown() string {
 
 // <commercial_version> elements are upsell links.
 type commercialVersion struct {
-	Content string `xml:",chardata"`
+	Content   string `xml:",chardata"`
+	upsellURL string
 }
 
+// ToMarkdown renders an upsell link, or plain text without an upsell URL.
 func (e *commercialVersion) ToMarkdown() string {
-	return fmt.Sprintf("[%s](%s)", e.Content, current.upsellURL)
+	if e.upsellURL == "" {
+		return e.Content
+	}
+	return fmt.Sprintf("[%s](%s)", e.Content, e.upsellURL)
+}
+
+// decodeXML keeps the upsell URL of the Reference being parsed.
+func (e *commercialVersion) decodeXML(d *xml.Decoder, start xml.StartElement, ctx *parseContext) error {
+	if ctx == nil {
+		return errNoContext
+	}
+	var v struct {
+		Content string `xml:",chardata"`
+	}
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	*e = commercialVersion{Content: v.Content, upsellURL: ctx.ref.upsellURL}
+	return nil
 }
 
 // <note> elements highlight some quirks or changes over time, rendered as
 // blockquotes.
 type note struct {
-	content string
+	content    string
+	commercial CommercialScope
 }
 
 func (n *note) ToMarkdown() string { return n.content }
 
-// UnmarshalXML processes the elements in-order to generate correct content.
+// decodeXML processes the elements in-order to generate correct content.
 // Some <note>s contain <literal>s, so needs to be parsed in-order.
-func (n *note) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
-	content, err := unmarshalMarkdownXML(d, start)
+func (n *note) decodeXML(d *xml.Decoder, start xml.StartElement, ctx *parseContext) error {
+	nodes, err := decodeMarkdownNodes(d, start, ctx)
 	if err != nil {
 		return err
 	}
+	content := joinMarkdown(nodes)
 	// add a '>' prefix to each line
 	var sb strings.Builder
 	for line := range strings.SplitSeq(content, "\n") {
@@ -190,6 +315,6 @@ func (n *note) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 		}
 		fmt.Fprintf(&sb, "> %s\n", line)
 	}
-	*n = note{content: sb.String()}
+	*n = note{content: sb.String(), commercial: commercialScope(nodes)}
 	return nil
 }
@@ -0,0 +1,49 @@
+package parse_test
+
+import (
+	"testing"
+
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/parse"
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/tarball"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModule_SummaryAndExample(t *testing.T) {
+	t.Parallel()
+	f := tarball.File{
+		Name: "/xml/en/test.xml",
+		Contents: []byte(`<!DOCTYPE module SYSTEM "../dtd/module.dtd">
+		<module link="/en/test.html" lang="en">
+		<section id="summary">
+		<para>The <literal>test</literal> module does things.</para>
+		</section>
+		<section id="example" name="Example Configuration">
+		<para>
+		<example>
+location / {
+    test on;
+}
+</example>
+		</para>
+		<para>Turns it on.</para>
+		</section>
+		<section id="directives"><directive name="test"/></section>
+		</module>`),
+	}
+	ref, err := parse.Parse([]tarball.File{f}, baseURL, upsellURL)
+	require.NoError(t, err)
+	m := ref.Modules[0]
+
+	require.Equal(t, "The `test` module does things.", m.Summary().ToMarkdown())
+
+	example := m.Example()
+	require.Len(t, example, 2)
+	require.Equal(t, []string{lines(
+		"location / {",
+		"    test on;",
+		"}",
+	)}, example[0].Examples)
+	require.Empty(t, example[1].Examples)
+
+	require.Empty(t, (&parse.Module{}).Summary())
+}
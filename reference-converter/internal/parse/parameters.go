@@ -0,0 +1,72 @@
+package parse
+
+import "strings"
+
+// Parameter documents a directive parameter, from the tag lists in the
+// directive's prose.
+type Parameter struct {
+	Name         string // e.g. backlog
+	Value        string // placeholder for the value, e.g. number
+	Prose        Prose
+	AppearedIn   string
+	IsCommercial bool
+}
+
+// genericParams are the placeholders used by syntaxes that take parameters
+// that are not spelled out, e.g. "server address [parameters]".
+var genericParams = []string{"parameters", "parameter", "options"}
+
+// syntaxParams returns the keywords the syntaxes accept, without any trailing
+// "=", and whether they take other parameters through a generic placeholder.
+func (ss Syntaxes) syntaxParams() (map[string]bool, bool) {
+	keywords := make(map[string]bool)
+	generic := false
+	for _, s := range ss {
+		for _, arg := range s.Arguments() {
+			for _, k := range arg.Keywords {
+				keywords[strings.TrimSuffix(k, "=")] = true
+			}
+			for _, p := range arg.Placeholders {
+				for _, g := range genericParams {
+					generic = generic || p == g
+				}
+			}
+		}
+	}
+	return keywords, generic
+}
+
+// Parameters extracts the documentation of each parameter from the tag lists
+// in the prose, when the tag names match the syntax.
+func (d *Directive) Parameters() []Parameter {
+	keywords, generic := d.Syntax.syntaxParams()
+	versions := make(map[string]string)
+	for _, pv := range d.Prose.ParamVersions() {
+		versions[pv.Name] = pv.AppearedIn
+	}
+
+	var params []Parameter
+	seen := make(map[string]bool)
+	for _, para := range d.Prose {
+		for _, tag := range para.TagList {
+			name := tag.Keyword
+			if name == "" || seen[name] || !(keywords[name] || generic) {
+				continue
+			}
+			seen[name] = true
+
+			appearedIn := firstVersion(tag.Desc.ToMarkdown())
+			if appearedIn == "" {
+				appearedIn = versions[name]
+			}
+			params = append(params, Parameter{
+				Name:         name,
+				Value:        tag.Value,
+				Prose:        Prose{tag.Desc},
+				AppearedIn:   appearedIn,
+				IsCommercial: Prose{tag.Desc}.IsCommercial(CommercialParameter, CommercialUnspecified),
+			})
+		}
+	}
+	return params
+}
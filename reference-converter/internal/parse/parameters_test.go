@@ -0,0 +1,82 @@
+package parse_test
+
+import (
+	"testing"
+
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/parse"
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/tarball"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirective_Parameters(t *testing.T) {
+	t.Parallel()
+	params := `<list type="tag">
+	<tag-name id="reuseport"><literal>reuseport</literal></tag-name>
+	<tag-desc>this parameter (1.9.1) instructs to create a socket</tag-desc>
+	<tag-name id="backlog"><literal>backlog</literal>=<value>number</value></tag-name>
+	<tag-desc>sets the <literal>backlog</literal></tag-desc>
+	<tag-name><literal>weight</literal>=<value>number</value></tag-name>
+	<tag-desc>sets the weight</tag-desc>
+	</list>`
+	reuseport := parse.Parameter{
+		Name:       "reuseport",
+		Prose:      parse.Prose{{Content: "this parameter (1.9.1) instructs to create a socket"}},
+		AppearedIn: "1.9.1",
+	}
+	backlog := parse.Parameter{
+		Name:  "backlog",
+		Value: "number",
+		Prose: parse.Prose{{Content: "sets the `backlog`"}},
+	}
+	weight := parse.Parameter{
+		Name:  "weight",
+		Value: "number",
+		Prose: parse.Prose{{Content: "sets the weight"}},
+	}
+
+	testcases := map[string]struct {
+		syntax  string
+		content string
+		want    []parse.Parameter
+	}{
+		"matches the syntax": {
+			syntax:  "<value>address</value> [<literal>reuseport</literal>] [<literal>backlog</literal>=<value>number</value>]",
+			content: params,
+			want:    []parse.Parameter{reuseport, backlog},
+		},
+		"generic parameters": {
+			syntax:  "<value>address</value> [<value>parameters</value>]",
+			content: params,
+			want:    []parse.Parameter{reuseport, backlog, weight},
+		},
+		"unrelated tag list": {
+			syntax:  "<value>address</value>",
+			content: params,
+		},
+		"inline version": {
+			syntax: "[<literal>backlog</literal>=<value>number</value>]",
+			content: `The <literal>backlog</literal> parameter (1.1.1) is documented below.
+			<list type="tag">
+			<tag-name><literal>backlog</literal>=<value>number</value></tag-name>
+			<tag-desc>sets the <literal>backlog</literal></tag-desc>
+			</list>`,
+			want: []parse.Parameter{{
+				Name:       "backlog",
+				Value:      "number",
+				Prose:      parse.Prose{{Content: "sets the `backlog`"}},
+				AppearedIn: "1.1.1",
+			}},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			f := testModuleFile(t, withSyntax(tc.syntax, false), withContent(tc.content))
+			ref, err := parse.Parse([]tarball.File{f}, baseURL, upsellURL)
+			require.NoError(t, err)
+
+			got := ref.Modules[0].Sections[0].Directives[0].Parameters()
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
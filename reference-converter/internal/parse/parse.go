@@ -1,33 +1,63 @@
 package parse
 
 import (
+	"context"
 	"fmt"
-	"sync"
 
 	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/tarball"
 )
 
-// current refers to the Reference currently being parsed.
-//
-// xml.Decoder.Decode gives no way to pass contextual information. Deep in the
-// XML tree we need to know things like the current module being parsed or an
-// attribute from another XML file via relative path.
-var current *Reference
-var currentMu sync.Mutex // protects current
+type config struct {
+	workers int
+}
+
+type Option = func(*config)
+
+// WithWorkers parses up to n files at once, defaults to 1.
+func WithWorkers(n int) Option {
+	return func(o *config) { o.workers = n }
+}
+
+// Source calls fn for every XML file of the docs, like tarball.Walk. Parsing
+// goes over the files twice, so it must yield the same files on every call.
+type Source = func(ctx context.Context, fn func(tarball.File) error) error
+
+// Files is a Source for files already in memory.
+func Files(files []tarball.File) Source {
+	return func(ctx context.Context, fn func(tarball.File) error) error {
+		for _, f := range files {
+			if err := fn(f); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
 
 // Parse reads and parses all the XML files, converting prose to markdown on the
-// way to respect the ordering of XML elements.
-func Parse(xmlFiles []tarball.File, baseURL, upsellURL string) (*Reference, error) {
-	ref := &Reference{baseURL: baseURL, upsellURL: upsellURL}
+// way to respect the ordering of XML elements. It is safe to call concurrently.
+func Parse(xmlFiles []tarball.File, baseURL, upsellURL string, opts ...Option) (*Reference, error) {
+	return ParseSource(context.Background(), Files(xmlFiles), baseURL, upsellURL, opts...)
+}
+
+// ParseSource works like Parse, without needing all the files in memory. The
+// first pass over src only keeps the names and links of the pages, the second
+// parses the files as they come.
+func ParseSource(ctx context.Context, src Source, baseURL, upsellURL string, opts ...Option) (*Reference, error) {
+	cfg := config{workers: 1}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	ref := &Reference{baseURL: baseURL, upsellURL: upsellURL, workers: cfg.workers}
 
 	// read all the files so we can build links
-	if err := ref.parsePages(xmlFiles); err != nil {
+	if err := ref.parsePages(ctx, src); err != nil {
 		return nil, fmt.Errorf("failed to parse articles: %w", err)
 	}
 
-	// read all modules
-	if err := ref.parseModules(xmlFiles); err != nil {
-		return nil, fmt.Errorf("failed to parse modules: %w", err)
+	// read all modules and articles, e.g. guides
+	if err := ref.parseDocs(ctx, src); err != nil {
+		return nil, err
 	}
 
 	return ref, nil
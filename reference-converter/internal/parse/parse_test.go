@@ -1,7 +1,12 @@
 package parse_test
 
 import (
+	"context"
 	"encoding/xml"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
 	"testing"
 
 	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/parse"
@@ -23,9 +28,11 @@ func TestParse(t *testing.T) {
 				Name:    "Module ngx_FAKE_TEST_module",
 				Link:    "/en/docs/FAKE/ngx_FAKE_TEST_module.html",
 				Lang:    "en",
+				Rev:     106,
 				Sections: []parse.Section{
 					{
-						ID: "directives",
+						ID:   "directives",
+						Name: "Directives",
 						Directives: []parse.Directive{
 							{
 								Name:     "testing",
@@ -33,7 +40,18 @@ func TestParse(t *testing.T) {
 								Contexts: []string{"http", "server", "location"},
 								Syntax: parse.Syntaxes{{
 									Content: "`on` | `off`",
+									AST: parse.SyntaxNode{
+										Kind: parse.SyntaxSequence,
+										Children: []parse.SyntaxNode{{
+											Kind: parse.SyntaxAlternatives,
+											Children: []parse.SyntaxNode{
+												{Kind: parse.SyntaxLiteral, Value: "on"},
+												{Kind: parse.SyntaxLiteral, Value: "off"},
+											},
+										}},
+									},
 								}},
+								AppearedIn: "1.11.8",
 								Prose: parse.Prose{
 									{Content: "\nFree form test.\n"},
 									{Content: "\nCan have more than one, with some html—ish entities and `verbatim` text.\n"},
@@ -68,9 +86,11 @@ func TestParse(t *testing.T) {
 				Name:    "Module ngx_FAKE_TEST_module",
 				Link:    "/en/docs/FAKE/ngx_FAKE_TEST_module.html",
 				Lang:    "en",
+				Rev:     106,
 				Sections: []parse.Section{
 					{
-						ID: "directives",
+						ID:   "directives",
+						Name: "Directives",
 						Directives: []parse.Directive{
 							{Name: "who_needs_closing_tags"},
 						},
@@ -103,3 +123,72 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestParse_Concurrent(t *testing.T) {
+	t.Parallel()
+	f := testModuleFile(t, withContent(`See <link id="a"/> and <commercial_version>NGINX Plus</commercial_version>.`))
+
+	var wg sync.WaitGroup
+	refs := make([]*parse.Reference, 8)
+	errs := make([]error, len(refs))
+	for i := range refs {
+		wg.Go(func() {
+			refs[i], errs[i] = parse.Parse([]tarball.File{f}, fmt.Sprintf("http://%d.example.org", i), fmt.Sprintf("http://%d.example.com", i))
+		})
+	}
+	wg.Wait()
+
+	for i, ref := range refs {
+		require.NoError(t, errs[i])
+		md := ref.Modules[0].Sections[0].Directives[0].Prose.ToMarkdown()
+		want := fmt.Sprintf("See [`a`](http://%d.example.org/en/test.html#a) and [NGINX Plus](http://%d.example.com).", i, i)
+		require.Equal(t, want, md)
+	}
+}
+
+func TestParse_ConcurrentIncomplete(t *testing.T) {
+	t.Parallel()
+	f := readTestFile(t, "incomplete.xml")
+	// spare capacity, so appending the missing closing tag in place would
+	// write to the shared array
+	f.Contents = append(make([]byte, 0, len(f.Contents)+64), f.Contents...)
+	contents := slices.Clone(f.Contents)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			ref, err := parse.Parse([]tarball.File{f}, baseURL, upsellURL)
+			require.NoError(t, err)
+			require.Len(t, ref.Modules, 1)
+		})
+	}
+	wg.Wait()
+	require.Equal(t, contents, f.Contents, "leaves the caller's file alone")
+}
+
+func TestParseSource(t *testing.T) {
+	t.Parallel()
+	files := []tarball.File{
+		testModuleFile(t, withContent(`See <link doc="docs/guide.xml"/>.`)),
+		testArticleFile("/xml/en/docs/guide.xml", "A guide"),
+	}
+	passes := 0
+	src := func(ctx context.Context, fn func(tarball.File) error) error {
+		passes++
+		return parse.Files(files)(ctx, fn)
+	}
+
+	ref, err := parse.ParseSource(context.Background(), src, baseURL, upsellURL)
+	require.NoError(t, err)
+	require.Equal(t, 2, passes, "one pass for the page index, one to parse")
+	require.Len(t, ref.Modules, 1)
+	require.Len(t, ref.Articles, 1)
+	require.Equal(t, "See [A guide](http://example.org/en/docs/guide.html).",
+		ref.Modules[0].Sections[0].Directives[0].Prose.ToMarkdown())
+
+	failing := func(ctx context.Context, fn func(tarball.File) error) error {
+		return errors.New("truncated tarball")
+	}
+	_, err = parse.ParseSource(context.Background(), failing, baseURL, upsellURL)
+	require.ErrorContains(t, err, "truncated tarball")
+}
@@ -1,7 +1,9 @@
 package parse
 
 import (
-	"path"
+	"context"
+	"fmt"
+	"log/slog"
 	"strings"
 
 	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/tarball"
@@ -9,66 +11,90 @@ import (
 
 // Reference is the collection of parsed docs for NGINX
 type Reference struct {
-	Modules     []*Module       // parsed and processed NGINX modules
-	baseURL     string          // where the official docs live
-	upsellURL   string          // where we link people when pushing the NGINX+
-	pages       map[string]page // used to build links from directives
-	currentPage page            // file currently being parsed, used to build links
+	Modules   []*Module       // parsed and processed NGINX modules
+	Articles  []*Article      // parsed and processed guides
+	baseURL   string          // where the official docs live
+	upsellURL string          // where we link people when pushing the NGINX+
+	pages     map[string]page // used to build links from directives
+	workers   int             // how many files to parse at once
 }
 
-func (r *Reference) parsePages(files []tarball.File) error {
+func (r *Reference) parsePages(ctx context.Context, src Source) error {
 	r.pages = make(map[string]page)
 
-	for _, f := range files {
+	return src(ctx, func(f tarball.File) error {
 		if f.Contains("dtd/article.dtd") || f.Contains("dtd/module.dtd") {
 			p := page{path: f.Name}
-			if err := unmarshalXML(&p, f); err != nil {
+			if err := unmarshalXML(&p, f, &parseContext{ref: r}); err != nil {
 				return err
 			}
 			r.pages[p.path] = p
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
-func (r *Reference) parseModules(files []tarball.File) error {
-	currentMu.Lock()
-	defer currentMu.Unlock()
-	// set the context for UnmarshalXML implementations to read during parsing
-	current = r
-	defer func() { current = nil }()
+// doc is a parsed module or article.
+type doc struct {
+	file     string
+	isModule bool
+	module   *Module
+	article  *Article
+}
 
-	for _, f := range files {
-		if !f.Contains("dtd/module.dtd") || strings.HasSuffix(f.Name, "_head.xml") {
-			continue
+// parseDocs reads every module, and the articles under docs/ which are the
+// guides. Articles are free-form and are not needed for the directive
+// reference, so the ones that fail to parse are skipped.
+func (r *Reference) parseDocs(ctx context.Context, src Source) error {
+	isDoc := func(f tarball.File) bool {
+		if strings.HasSuffix(f.Name, "_head.xml") {
+			return false
 		}
-		res, err := r.parseModule(f)
-		if err != nil {
-			return err
+		return f.Contains("dtd/module.dtd") || (f.Contains("dtd/article.dtd") && strings.Contains(f.Name, "/docs/"))
+	}
+	docs, errs, err := parseStream(ctx, src, r.workers, isDoc, r.parseDoc)
+	if err != nil {
+		return err
+	}
+
+	for i, d := range docs {
+		switch {
+		case errs[i] == nil && d.isModule:
+			r.Modules = append(r.Modules, d.module)
+		case errs[i] == nil:
+			r.Articles = append(r.Articles, d.article)
+		case d.isModule:
+			return fmt.Errorf("failed to parse modules: %w", errs[i])
+		default:
+			slog.Warn("skipped article", slog.String("file", d.file), slog.Any("error", errs[i]))
 		}
-		r.Modules = append(r.Modules, res)
 	}
 	return nil
 }
 
-func (r *Reference) parseModule(f tarball.File) (*Module, error) {
-	// set the context for UnmarshalXML implementations to read during parsing
-	r.currentPage = r.pages[f.Name]
-	defer func() { r.currentPage = page{} }()
+func (r *Reference) parseDoc(f tarball.File) (doc, error) {
+	d := doc{file: f.Name, isModule: f.Contains("dtd/module.dtd")}
+	var err error
+	if d.isModule {
+		d.module, err = r.parseModule(f)
+	} else {
+		d.article, err = r.parseArticle(f)
+	}
+	return d, err
+}
 
-	var res Module
-	if err := unmarshalXML(&res, f); err != nil {
+func (r *Reference) parseArticle(f tarball.File) (*Article, error) {
+	var res Article
+	if err := unmarshalXML(&res, f, &parseContext{ref: r, page: r.pages[f.Name]}); err != nil {
 		return nil, err
 	}
 	return &res, nil
 }
 
-// getPage looks up another page, given relative path from the currentModule.
-func (r *Reference) getPage(relpath string) (page, bool) {
-	if relpath == "" {
-		return page{}, false
+func (r *Reference) parseModule(f tarball.File) (*Module, error) {
+	var res Module
+	if err := unmarshalXML(&res, f, &parseContext{ref: r, page: r.pages[f.Name]}); err != nil {
+		return nil, err
 	}
-	p := path.Join(path.Dir(r.currentPage.path), relpath)
-	page, ok := r.pages[p]
-	return page, ok
+	return &res, nil
 }
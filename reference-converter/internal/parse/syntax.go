@@ -0,0 +1,365 @@
+package parse
+
+import (
+	"log/slog"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SyntaxKind is the type of a node in a syntax grammar tree.
+type SyntaxKind string
+
+const (
+	SyntaxSequence     SyntaxKind = "sequence"     // whitespace separated arguments
+	SyntaxConcat       SyntaxKind = "concat"       // parts of a single argument, e.g. address[:port]
+	SyntaxAlternatives SyntaxKind = "alternatives" // a | b
+	SyntaxOptional     SyntaxKind = "optional"     // [a]
+	SyntaxRepeat       SyntaxKind = "repeat"       // a ...
+	SyntaxLiteral      SyntaxKind = "literal"      // keywords, e.g. <literal>on</literal>
+	SyntaxPlaceholder  SyntaxKind = "placeholder"  // values, e.g. <value>size</value>
+	SyntaxBlock        SyntaxKind = "block"        // { ... }
+)
+
+// SyntaxNode is a node in the grammar tree of a <syntax>. The root is always a
+// SyntaxSequence of the directive arguments, ending with a SyntaxBlock for
+// block directives.
+type SyntaxNode struct {
+	Kind     SyntaxKind
+	Value    string // for literals and placeholders
+	Children []SyntaxNode
+}
+
+type syntaxTokenKind int
+
+const (
+	tokSpace syntaxTokenKind = iota
+	tokOr
+	tokOpen
+	tokClose
+	tokEllipsis
+	tokLiteral
+	tokPlaceholder
+)
+
+type syntaxToken struct {
+	kind  syntaxTokenKind
+	value string
+}
+
+// lexSyntax splits the children of a <syntax> into tokens. Text between
+// elements carries the operators, and sometimes bits of literals like the "="
+// in "[<literal>opt</literal>=<value>val</value>]".
+func lexSyntax(nodes []markdownNode) []syntaxToken {
+	var toks []syntaxToken
+	for _, n := range nodes {
+		switch n.name {
+		case "":
+			toks = append(toks, lexSyntaxText(string(n.md.(text)))...)
+		case "value":
+			toks = append(toks, syntaxToken{kind: tokPlaceholder, value: n.md.(*code).Content})
+		default:
+			value := n.md.ToMarkdown()
+			if c, ok := n.md.(*code); ok {
+				value = c.Content
+			}
+			toks = append(toks, syntaxToken{kind: tokLiteral, value: value})
+		}
+	}
+	return toks
+}
+
+func lexSyntaxText(s string) []syntaxToken {
+	var toks []syntaxToken
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		switch {
+		case unicode.IsSpace(r):
+			s = strings.TrimLeftFunc(s, unicode.IsSpace)
+			toks = append(toks, syntaxToken{kind: tokSpace})
+			continue
+		case r == '|':
+			toks = append(toks, syntaxToken{kind: tokOr})
+		case r == '[':
+			toks = append(toks, syntaxToken{kind: tokOpen})
+		case r == ']':
+			toks = append(toks, syntaxToken{kind: tokClose})
+		case strings.HasPrefix(s, "..."):
+			toks = append(toks, syntaxToken{kind: tokEllipsis})
+			s = s[3:]
+			continue
+		default:
+			end := strings.IndexFunc(s, func(r rune) bool {
+				return unicode.IsSpace(r) || strings.ContainsRune("|[]", r)
+			})
+			if dots := strings.Index(s, "..."); dots >= 0 && (end < 0 || dots < end) {
+				end = dots
+			}
+			if end < 0 {
+				end = len(s)
+			}
+			// always consume at least one rune, so the loop ends whatever the input
+			end = max(end, size)
+			toks = append(toks, syntaxToken{kind: tokLiteral, value: s[:end]})
+			s = s[end:]
+			continue
+		}
+		s = s[size:]
+	}
+	return toks
+}
+
+// syntaxParser is a recursive descent parser for the grammar:
+//
+//	alternatives = sequence { "|" sequence }
+//	sequence     = word { space word | space "..." }
+//	word         = atom { atom | "..." }
+//	atom         = literal | placeholder | "[" alternatives "]"
+//
+// It is lenient, unbalanced brackets are ignored or closed at the end.
+type syntaxParser struct {
+	toks []syntaxToken
+	pos  int
+}
+
+func (p *syntaxParser) peek() (syntaxTokenKind, bool) {
+	if p.pos >= len(p.toks) {
+		return 0, false
+	}
+	return p.toks[p.pos].kind, true
+}
+
+func (p *syntaxParser) skipSpace() {
+	for k, ok := p.peek(); ok && k == tokSpace; k, ok = p.peek() {
+		p.pos++
+	}
+}
+
+func (p *syntaxParser) alternatives() SyntaxNode {
+	branches := []SyntaxNode{p.sequence()}
+	for k, ok := p.peek(); ok && k == tokOr; k, ok = p.peek() {
+		p.pos++
+		branches = append(branches, p.sequence())
+	}
+	if len(branches) == 1 {
+		return branches[0]
+	}
+	return SyntaxNode{Kind: SyntaxAlternatives, Children: branches}
+}
+
+func (p *syntaxParser) sequence() SyntaxNode {
+	var words []SyntaxNode
+	for {
+		p.skipSpace()
+		k, ok := p.peek()
+		if !ok || k == tokOr || k == tokClose {
+			break
+		}
+		if k == tokEllipsis {
+			p.pos++
+			words = repeatLast(words)
+			continue
+		}
+		words = append(words, p.word())
+	}
+	return collapse(SyntaxSequence, words)
+}
+
+func (p *syntaxParser) word() SyntaxNode {
+	var atoms []SyntaxNode
+LOOP:
+	for {
+		k, ok := p.peek()
+		if !ok {
+			break
+		}
+		switch k {
+		case tokLiteral:
+			value := p.toks[p.pos].value
+			p.pos++
+			// merge adjacent literals, e.g. <literal>opt</literal>=
+			if n := len(atoms); n > 0 && atoms[n-1].Kind == SyntaxLiteral {
+				atoms[n-1].Value += value
+				continue
+			}
+			atoms = append(atoms, SyntaxNode{Kind: SyntaxLiteral, Value: value})
+		case tokPlaceholder:
+			atoms = append(atoms, SyntaxNode{Kind: SyntaxPlaceholder, Value: p.toks[p.pos].value})
+			p.pos++
+		case tokOpen:
+			p.pos++
+			inner := p.alternatives()
+			if k, ok := p.peek(); ok && k == tokClose {
+				p.pos++
+			}
+			atoms = append(atoms, SyntaxNode{Kind: SyntaxOptional, Children: []SyntaxNode{inner}})
+		case tokEllipsis:
+			p.pos++
+			atoms = repeatLast(atoms)
+		default:
+			break LOOP
+		}
+	}
+	return collapse(SyntaxConcat, atoms)
+}
+
+// repeatLast marks the last node as repeatable.
+func repeatLast(nodes []SyntaxNode) []SyntaxNode {
+	n := len(nodes)
+	if n == 0 {
+		return append(nodes, SyntaxNode{Kind: SyntaxLiteral, Value: "..."})
+	}
+	nodes[n-1] = SyntaxNode{Kind: SyntaxRepeat, Children: []SyntaxNode{nodes[n-1]}}
+	return nodes
+}
+
+// collapse avoids single child sequences and concatenations.
+func collapse(kind SyntaxKind, children []SyntaxNode) SyntaxNode {
+	if len(children) == 1 {
+		return children[0]
+	}
+	return SyntaxNode{Kind: kind, Children: children}
+}
+
+// parseSyntax builds the grammar tree for the children of a <syntax>.
+func parseSyntax(nodes []markdownNode, isBlock bool) SyntaxNode {
+	p := &syntaxParser{toks: lexSyntax(nodes)}
+	root := SyntaxNode{Kind: SyntaxSequence}
+	for {
+		p.skipSpace()
+		if _, ok := p.peek(); !ok {
+			break
+		}
+		n := p.alternatives()
+		if n.Kind == SyntaxSequence {
+			root.Children = append(root.Children, n.Children...)
+		} else {
+			root.Children = append(root.Children, n)
+		}
+		// a stray "]" would stop the parser, skip it
+		if k, ok := p.peek(); ok && k == tokClose {
+			slog.Warn("unbalanced ] in syntax", slog.String("syntax", joinMarkdown(nodes)))
+			p.pos++
+		}
+	}
+	if isBlock {
+		root.Children = append(root.Children, SyntaxNode{Kind: SyntaxBlock})
+	}
+	return root
+}
+
+// ToMarkdown renders the node the way the docs write syntaxes, e.g.
+// "*`address`*[:*`port`*] [`backlog`=*`number`*]".
+func (n SyntaxNode) ToMarkdown() string {
+	join := func(sep string) string {
+		parts := make([]string, 0, len(n.Children))
+		for _, c := range n.Children {
+			parts = append(parts, c.ToMarkdown())
+		}
+		return strings.Join(parts, sep)
+	}
+	switch n.Kind {
+	case SyntaxLiteral:
+		// punctuation comes from the text between elements, e.g. the ":" of
+		// address[:port], and so does the "=" of named parameters
+		if strings.IndexFunc(n.Value, isAlnum) < 0 {
+			return n.Value
+		}
+		if name, ok := strings.CutSuffix(n.Value, "="); ok {
+			return "`" + name + "`="
+		}
+		return "`" + n.Value + "`"
+	case SyntaxPlaceholder:
+		return "*`" + n.Value + "`*"
+	case SyntaxBlock:
+		return "`{...}`"
+	case SyntaxOptional:
+		return "[" + join("") + "]"
+	case SyntaxRepeat:
+		return join("") + " ..."
+	case SyntaxConcat:
+		return join("")
+	case SyntaxAlternatives:
+		return join(" | ")
+	default:
+		return join(" ")
+	}
+}
+
+func isAlnum(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+
+// Without removes the parameters from the syntaxes, e.g. "status_zone" drops
+// [status_zone=zone]. Syntaxes requiring one of them are removed. The markdown
+// of the syntaxes that changed is rendered from their grammar tree.
+func (ss Syntaxes) Without(params map[string]bool) Syntaxes {
+	if len(params) == 0 {
+		return ss
+	}
+	ret := make(Syntaxes, 0, len(ss))
+	for _, s := range ss {
+		children, ok := withoutParams(s.AST.Children, params)
+		switch {
+		case !ok:
+			continue
+		case !reflect.DeepEqual(children, s.AST.Children):
+			s.AST = SyntaxNode{Kind: SyntaxSequence, Children: children}
+			s.Content = s.AST.ToMarkdown()
+		}
+		ret = append(ret, s)
+	}
+	return ret
+}
+
+// withoutParams prunes a sequence or concatenation of nodes, dropping the
+// optional nodes that lose a parameter. It fails when a required node does.
+func withoutParams(nodes []SyntaxNode, params map[string]bool) ([]SyntaxNode, bool) {
+	var ret []SyntaxNode
+	for _, n := range nodes {
+		pruned, ok := withoutParam(n, params, true)
+		switch {
+		case ok:
+			ret = append(ret, pruned)
+		case n.Kind != SyntaxOptional:
+			return nil, false
+		}
+	}
+	return ret, true
+}
+
+// withoutParam prunes n, leading tells whether it starts an argument, as only
+// then a literal is a parameter name.
+func withoutParam(n SyntaxNode, params map[string]bool, leading bool) (SyntaxNode, bool) {
+	switch n.Kind {
+	case SyntaxLiteral:
+		return n, !leading || !params[strings.TrimSuffix(n.Value, "=")]
+	case SyntaxSequence:
+		children, ok := withoutParams(n.Children, params)
+		if !ok || len(children) == 0 {
+			return n, false
+		}
+		return collapse(SyntaxSequence, children), true
+	case SyntaxConcat:
+		// only the leading literal is a parameter, the rest is the value
+		if _, ok := withoutParam(n.Children[0], params, leading); !ok {
+			return n, false
+		}
+		return n, true
+	case SyntaxOptional, SyntaxRepeat:
+		child, ok := withoutParam(n.Children[0], params, leading)
+		return SyntaxNode{Kind: n.Kind, Children: []SyntaxNode{child}}, ok
+	case SyntaxAlternatives:
+		var branches []SyntaxNode
+		for _, c := range n.Children {
+			if b, ok := withoutParam(c, params, leading); ok {
+				branches = append(branches, b)
+			}
+		}
+		if len(branches) == 0 {
+			return n, false
+		}
+		return collapse(SyntaxAlternatives, branches), true
+	default:
+		return n, true
+	}
+}
@@ -0,0 +1,170 @@
+package parse_test
+
+import (
+	"testing"
+
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/parse"
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/tarball"
+	"github.com/stretchr/testify/require"
+)
+
+func lit(v string) parse.SyntaxNode { return parse.SyntaxNode{Kind: parse.SyntaxLiteral, Value: v} }
+func ph(v string) parse.SyntaxNode  { return parse.SyntaxNode{Kind: parse.SyntaxPlaceholder, Value: v} }
+func node(kind parse.SyntaxKind, children ...parse.SyntaxNode) parse.SyntaxNode {
+	return parse.SyntaxNode{Kind: kind, Children: children}
+}
+
+func TestSyntax_AST(t *testing.T) {
+	t.Parallel()
+	var (
+		seq  = func(c ...parse.SyntaxNode) parse.SyntaxNode { return node(parse.SyntaxSequence, c...) }
+		alt  = func(c ...parse.SyntaxNode) parse.SyntaxNode { return node(parse.SyntaxAlternatives, c...) }
+		opt  = func(c parse.SyntaxNode) parse.SyntaxNode { return node(parse.SyntaxOptional, c) }
+		rep  = func(c parse.SyntaxNode) parse.SyntaxNode { return node(parse.SyntaxRepeat, c) }
+		cat  = func(c ...parse.SyntaxNode) parse.SyntaxNode { return node(parse.SyntaxConcat, c...) }
+		blk  = parse.SyntaxNode{Kind: parse.SyntaxBlock}
+		none = seq()
+	)
+	testcases := map[string]struct {
+		syntax    string
+		blockAttr bool
+		want      parse.SyntaxNode
+	}{
+		"no args": {
+			syntax: "",
+			want:   none,
+		},
+		"block": {
+			syntax:    "",
+			blockAttr: true,
+			want:      seq(blk),
+		},
+		"flag": {
+			syntax: "<literal>on</literal> | <literal>off</literal>",
+			want:   seq(alt(lit("on"), lit("off"))),
+		},
+		"args": {
+			syntax: "<value>argA</value> <value>argB</value>",
+			want:   seq(ph("argA"), ph("argB")),
+		},
+		"mixed alternatives": {
+			syntax: "<value>address</value> | <value>CIDR</value> | <literal>unix:</literal> | <literal>all</literal>",
+			want:   seq(alt(ph("address"), ph("CIDR"), lit("unix:"), lit("all"))),
+		},
+		"optional flags across lines": {
+			syntax: lines(
+				"",
+				"    [<literal>SSLv2</literal>]",
+				"    [<literal>SSLv3</literal>]",
+			),
+			want: seq(opt(lit("SSLv2")), opt(lit("SSLv3"))),
+		},
+		"named option": {
+			syntax: "<value>arg</value> [<literal>opt</literal>=<value>val</value>]",
+			want:   seq(ph("arg"), opt(cat(lit("opt="), ph("val")))),
+		},
+		"optional part of an argument": {
+			syntax: "<value>address</value>[:<value>port</value>]",
+			want:   seq(cat(ph("address"), opt(cat(lit(":"), ph("port"))))),
+		},
+		"nested optional": {
+			syntax: "<value>code</value> ... [<literal>=</literal>[<value>response</value>]] <value>uri</value>",
+			want: seq(
+				rep(ph("code")),
+				opt(cat(lit("="), opt(ph("response")))),
+				ph("uri"),
+			),
+		},
+		"repeat inside optional": {
+			syntax: "<value>path</value> [<value>format</value> [<value>param</value> ...]]",
+			want:   seq(ph("path"), opt(seq(ph("format"), opt(rep(ph("param")))))),
+		},
+		"adjacent repeat": {
+			syntax: "<value>name</value>...",
+			want:   seq(rep(ph("name"))),
+		},
+		"non-breaking space": {
+			syntax: "<value>a</value>\u00a0<value>b</value>",
+			want:   seq(ph("a"), ph("b")),
+		},
+		"unbalanced brackets": {
+			syntax: "<literal>a</literal>] [<literal>b</literal>",
+			want:   seq(lit("a"), opt(lit("b"))),
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			f := testModuleFile(t, withSyntax(tc.syntax, tc.blockAttr))
+			ref, err := parse.Parse([]tarball.File{f}, baseURL, upsellURL)
+			require.NoError(t, err)
+
+			got := ref.Modules[0].Sections[0].Directives[0].Syntax[0].AST
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestSyntaxes_Without(t *testing.T) {
+	t.Parallel()
+	testcases := map[string]struct {
+		syntax string
+		params []string
+		want   []string // markdown, nil when the syntax is dropped
+	}{
+		"nothing to drop": {
+			syntax: "<value>address</value>[:<value>port</value>] [<literal>backlog</literal>=<value>number</value>]",
+			params: []string{"ssl"},
+			want:   []string{"*`address`*[:*`port`*] [`backlog`=*`number`*]"},
+		},
+		"optional parameter": {
+			syntax: "<value>address</value> ... [<literal>valid</literal>=<value>time</value>] [<literal>status_zone</literal>=<value>zone</value>]",
+			params: []string{"status_zone"},
+			want:   []string{"*`address`* ... [`valid`=*`time`*]"},
+		},
+		"flag": {
+			syntax: "<value>address</value> [<literal>ssl</literal>] [<literal>http2</literal>]",
+			params: []string{"http2"},
+			want:   []string{"*`address`* [`ssl`]"},
+		},
+		"alternative": {
+			syntax: "<literal>off</literal> | <literal>on</literal> | <literal>build</literal>",
+			params: []string{"build"},
+			want:   []string{"`off` | `on`"},
+		},
+		"nested optional": {
+			syntax: "<value>path</value> [<value>format</value> [<literal>buffer</literal>=<value>size</value>]]",
+			params: []string{"buffer"},
+			want:   []string{"*`path`* [*`format`*]"},
+		},
+		"required parameter": {
+			syntax: "<literal>least_time</literal> <literal>header</literal> | <literal>last_byte</literal>",
+			params: []string{"least_time"},
+			want:   []string{"`last_byte`"},
+		},
+		"whole syntax": {
+			syntax: "<literal>zone</literal>=<value>name</value> <value>size</value>",
+			params: []string{"zone"},
+		},
+		"value is not a parameter": {
+			syntax: "[<literal>ipv6</literal>=<literal>on</literal>|<literal>off</literal>]",
+			params: []string{"on"},
+			want:   []string{"[`ipv6`=`on`|`off`]"},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			f := testModuleFile(t, withSyntax(tc.syntax, false))
+			ref, err := parse.Parse([]tarball.File{f}, baseURL, upsellURL)
+			require.NoError(t, err)
+
+			params := make(map[string]bool)
+			for _, p := range tc.params {
+				params[p] = true
+			}
+			got := ref.Modules[0].Sections[0].Directives[0].Syntax.Without(params)
+			require.Equal(t, tc.want, got.ToMarkdown())
+		})
+	}
+}
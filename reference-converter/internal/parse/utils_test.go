@@ -77,7 +77,7 @@ var moduleTemplate = template.Must(template.New("mod").Parse(`
 </module>
 `))
 
-func testModuleFile(t *testing.T, opts ...xmlOption) tarball.File {
+func testModuleFile(t testing.TB, opts ...xmlOption) tarball.File {
 	t.Helper()
 	cfg := xmlConfig{
 		AddPara: true,
@@ -0,0 +1,100 @@
+package parse
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ValueType classifies the values a placeholder accepts, e.g. <value>time</value>.
+type ValueType string
+
+const (
+	ValueTime    ValueType = "time"    // 30s, 1h 30m
+	ValueSize    ValueType = "size"    // 1024, 8k, 1m
+	ValueNumber  ValueType = "number"  // 42
+	ValueAddress ValueType = "address" // 127.0.0.1:8080, [::1], example.com, unix:/tmp/sock
+	ValueCIDR    ValueType = "cidr"    // 10.0.0.0/8
+	ValuePath    ValueType = "path"    // /var/log/nginx/access.log
+	ValueString  ValueType = "string"  // anything else
+)
+
+// ValueTypes are all the value types, in a stable order.
+var ValueTypes = []ValueType{ValueTime, ValueSize, ValueNumber, ValueAddress, ValueCIDR, ValuePath, ValueString}
+
+// valueTypeRules holds how to recognize a placeholder by its name, and how to
+// validate values following the NGINX config syntax, see
+// https://nginx.org/en/docs/syntax.html
+var valueTypeRules = map[ValueType]struct {
+	names       []string // placeholder names, or suffixes of them
+	pattern     *regexp.Regexp
+	description string
+}{
+	ValueTime: {
+		names:       []string{"time", "timeout", "interval", "duration"},
+		pattern:     regexp.MustCompile(`^\d+(ms|s|m|h|d|w|M|y)?( *\d+(ms|s|m|h|d|w|M|y)?)*$`),
+		description: "time interval, seconds by default, with an optional ms, s, m, h, d, w, M or y unit",
+	},
+	ValueSize: {
+		names:       []string{"size"},
+		pattern:     regexp.MustCompile(`^\d+[kKmMgG]?$`),
+		description: "size in bytes, with an optional k, m or g suffix",
+	},
+	ValueNumber: {
+		names:       []string{"number", "count", "level", "port", "weight"},
+		pattern:     regexp.MustCompile(`^\d+$`),
+		description: "non-negative integer",
+	},
+	ValueAddress: {
+		names:       []string{"address", "host", "hostname", "ip"},
+		pattern:     regexp.MustCompile(`^(unix:\S+|\[[0-9A-Fa-f:.]+\](:\d+)?|\*(:\d+)?|[A-Za-z0-9._-]+(:\d+)?)$`),
+		description: "IP address, hostname or unix: socket path, with an optional port",
+	},
+	ValueCIDR: {
+		names:       []string{"cidr"},
+		pattern:     regexp.MustCompile(`^([0-9.]+/\d{1,2}|[0-9A-Fa-f:.]+/\d{1,3})$`),
+		description: "IPv4 or IPv6 network in CIDR notation",
+	},
+	ValuePath: {
+		names:       []string{"path", "file", "dir", "directory"},
+		pattern:     regexp.MustCompile(`^\S+$`),
+		description: "file system path",
+	},
+	ValueString: {
+		description: "free-form string",
+	},
+}
+
+// ClassifyPlaceholder guesses the type of a placeholder from its name, e.g.
+// "time" or "max_size".
+func ClassifyPlaceholder(name string) ValueType {
+	name = strings.ToLower(name)
+	for _, vt := range ValueTypes {
+		for _, n := range valueTypeRules[vt].names {
+			if name == n || strings.HasSuffix(name, "_"+n) {
+				return vt
+			}
+		}
+	}
+	return ValueString
+}
+
+// Pattern is a regular expression matching valid values, empty when anything
+// goes.
+func (vt ValueType) Pattern() string {
+	if p := valueTypeRules[vt].pattern; p != nil {
+		return p.String()
+	}
+	return ""
+}
+
+func (vt ValueType) Description() string { return valueTypeRules[vt].description }
+
+// Validate reports whether v is a valid value of the type. Values with
+// variables are only known at runtime, so they are always valid.
+func (vt ValueType) Validate(v string) bool {
+	p := valueTypeRules[vt].pattern
+	if p == nil || strings.Contains(v, "$") {
+		return true
+	}
+	return p.MatchString(v)
+}
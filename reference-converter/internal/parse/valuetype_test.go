@@ -0,0 +1,73 @@
+package parse_test
+
+import (
+	"testing"
+
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/parse"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyPlaceholder(t *testing.T) {
+	t.Parallel()
+	testcases := map[string]parse.ValueType{
+		"time":           parse.ValueTime,
+		"timeout":        parse.ValueTime,
+		"size":           parse.ValueSize,
+		"max_size":       parse.ValueSize,
+		"number":         parse.ValueNumber,
+		"port":           parse.ValueNumber,
+		"address":        parse.ValueAddress,
+		"CIDR":           parse.ValueCIDR,
+		"path":           parse.ValuePath,
+		"file":           parse.ValuePath,
+		"format":         parse.ValueString,
+		"filesize_thing": parse.ValueString,
+	}
+	for name, want := range testcases {
+		require.Equal(t, want, parse.ClassifyPlaceholder(name), name)
+	}
+}
+
+func TestValueType_Validate(t *testing.T) {
+	t.Parallel()
+	testcases := map[parse.ValueType]struct {
+		valid, invalid []string
+	}{
+		parse.ValueTime: {
+			valid:   []string{"30", "30s", "500ms", "1h 30m", "1y"},
+			invalid: []string{"s", "30x", "1.5h", "-1s"},
+		},
+		parse.ValueSize: {
+			valid:   []string{"1024", "8k", "1M", "2g"},
+			invalid: []string{"k", "8kb", "1t"},
+		},
+		parse.ValueNumber: {
+			valid:   []string{"0", "42"},
+			invalid: []string{"", "4.2", "ten"},
+		},
+		parse.ValueAddress: {
+			valid:   []string{"127.0.0.1", "127.0.0.1:8080", "[::1]:80", "*:80", "example.com", "unix:/tmp/nginx.sock", "8080"},
+			invalid: []string{"", "unix:", "a b"},
+		},
+		parse.ValueCIDR: {
+			valid:   []string{"10.0.0.0/8", "2001:db8::/32"},
+			invalid: []string{"10.0.0.0", "10.0.0.0/x"},
+		},
+		parse.ValuePath: {
+			valid:   []string{"/var/log/nginx/access.log", "logs/error.log"},
+			invalid: []string{"", "a b"},
+		},
+		parse.ValueString: {
+			valid: []string{"", "anything goes"},
+		},
+	}
+	for vt, tc := range testcases {
+		for _, v := range tc.valid {
+			require.True(t, vt.Validate(v), "%s should accept %q", vt, v)
+		}
+		for _, v := range tc.invalid {
+			require.False(t, vt.Validate(v), "%s should reject %q", vt, v)
+		}
+	}
+	require.True(t, parse.ValueSize.Validate("$buffer_size"), "variables are always valid")
+}
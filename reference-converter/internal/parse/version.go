@@ -0,0 +1,89 @@
+package parse
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ParamVersion records the NGINX version a directive parameter appeared in.
+type ParamVersion struct {
+	Name       string
+	AppearedIn string
+}
+
+// versionMarker matches the inline markers the docs use to say when something
+// appeared, e.g. "(1.5.1)" or "(1.1.13, 1.0.12)". The first version is the
+// mainline one.
+var versionMarker = regexp.MustCompile(`\((\d+\.\d+\.\d+)(?:,\s*\d+\.\d+\.\d+)*\)`)
+
+// inlineParamVersion matches prose like "the `foo` parameter (1.5.1)" or
+// "the `foo` and `bar` parameters (1.1.13, 1.0.12)", including ones wrapped
+// inside a blockquote.
+var inlineParamVersion = regexp.MustCompile(
+	"((?:`[^`]+`(?:,\\s+and\\s+|,\\s*|\\s+and\\s+|\\s+or\\s+)?)+)\\s+parameters?\\s+(?:>\\s+)?" +
+		versionMarker.String())
+
+// tagListItem matches the first line of a rendered tag list entry where the
+// name is made of code spans only, e.g. "- `backlog`=*`number`*".
+var tagListItem = regexp.MustCompile("^- ((?:\\*?`[^`]+`\\*?|[=:\\[\\] ])+)$")
+
+var codeSpan = regexp.MustCompile("`([^`]+)`")
+
+// firstVersion returns the first version marker in s, if any.
+func firstVersion(s string) string {
+	m := versionMarker.FindStringSubmatch(s)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// ParamVersions extracts the versions of parameters mentioned in the prose,
+// either inline ("the `foo` parameter (1.5.1)") or as the first version marker
+// in the description of a tag list entry. The first mention of a parameter
+// wins.
+func (t Prose) ParamVersions() []ParamVersion {
+	var res []ParamVersion
+	seen := make(map[string]bool)
+	add := func(name, version string) {
+		name = strings.TrimSuffix(name, "=")
+		if name == "" || version == "" || seen[name] {
+			return
+		}
+		seen[name] = true
+		res = append(res, ParamVersion{Name: name, AppearedIn: version})
+	}
+
+	md := t.ToMarkdown()
+	for _, m := range inlineParamVersion.FindAllStringSubmatch(md, -1) {
+		for _, name := range codeSpan.FindAllStringSubmatch(m[1], -1) {
+			add(name[1], m[2])
+		}
+	}
+
+	// walk the tag lists, a description is indented below its name
+	var name string
+	var desc strings.Builder
+	flush := func() {
+		if name != "" {
+			add(name, firstVersion(desc.String()))
+		}
+		name = ""
+		desc.Reset()
+	}
+	for line := range strings.SplitSeq(md, "\n") {
+		switch {
+		case tagListItem.MatchString(line):
+			flush()
+			name = codeSpan.FindStringSubmatch(line)[1]
+		case line == "" || strings.HasPrefix(line, "    "):
+			desc.WriteString(line)
+			desc.WriteString("\n")
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	return res
+}
@@ -0,0 +1,66 @@
+package parse_test
+
+import (
+	"testing"
+
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/parse"
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/tarball"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProse_ParamVersions(t *testing.T) {
+	t.Parallel()
+	testcases := map[string]struct {
+		content string
+		want    []parse.ParamVersion
+	}{
+		"no versions": {
+			content: `The <literal>foo</literal> parameter is great.`,
+		},
+		"inline parameter": {
+			content: `The <literal>foo</literal> parameter (1.5.1) is great.`,
+			want:    []parse.ParamVersion{{Name: "foo", AppearedIn: "1.5.1"}},
+		},
+		"inline parameters with backports": {
+			content: lines(
+				"<note>",
+				"The <literal>TLSv1.1</literal> and <literal>TLSv1.2</literal> parameters",
+				"(1.1.13, 1.0.12) work only when OpenSSL 1.0.1 or higher is used.",
+				"</note>"),
+			want: []parse.ParamVersion{
+				{Name: "TLSv1.1", AppearedIn: "1.1.13"},
+				{Name: "TLSv1.2", AppearedIn: "1.1.13"},
+			},
+		},
+		"tag list": {
+			content: `<list type="tag">
+			<tag-name><literal>reuseport</literal></tag-name>
+			<tag-desc>this parameter (1.9.1) instructs to create a socket</tag-desc>
+			<tag-name><literal>backlog</literal>=<value>number</value></tag-name>
+			<tag-desc>sets the backlog</tag-desc>
+			<tag-name><literal>so_keepalive</literal>=<value>on</value></tag-name>
+			<tag-desc><para>configures keepalive (1.1.11)</para></tag-desc>
+			</list>`,
+			want: []parse.ParamVersion{
+				{Name: "reuseport", AppearedIn: "1.9.1"},
+				{Name: "so_keepalive", AppearedIn: "1.1.11"},
+			},
+		},
+		"first mention wins": {
+			content: `The <literal>foo</literal> parameter (1.5.1) replaces
+			the <literal>foo</literal> parameter (1.1.1).`,
+			want: []parse.ParamVersion{{Name: "foo", AppearedIn: "1.5.1"}},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			f := testModuleFile(t, withContent(tc.content))
+			ref, err := parse.Parse([]tarball.File{f}, baseURL, upsellURL)
+			require.NoError(t, err)
+
+			got := ref.Modules[0].Sections[0].Directives[0].Prose.ParamVersions()
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package parse
+
+import (
+	"context"
+	"sync"
+
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/tarball"
+)
+
+// parseStream calls parse for every file src yields that keep accepts, running
+// up to workers calls at once while src carries on. Results and errors are in
+// the same order as the files.
+func parseStream[T any](ctx context.Context, src Source, workers int, keep func(tarball.File) bool, parse func(tarball.File) (T, error)) ([]T, []error, error) {
+	var (
+		mu   sync.Mutex // protects res and errs, which grow as src yields files
+		res  []T
+		errs []error
+	)
+	type job struct {
+		i int
+		f tarball.File
+	}
+	jobs := make(chan job)
+	var wg sync.WaitGroup
+	for range max(1, workers) {
+		wg.Go(func() {
+			for j := range jobs {
+				v, err := parse(j.f)
+				mu.Lock()
+				res[j.i], errs[j.i] = v, err
+				mu.Unlock()
+			}
+		})
+	}
+
+	n := 0
+	err := src(ctx, func(f tarball.File) error {
+		if !keep(f) {
+			return nil
+		}
+		mu.Lock()
+		var zero T
+		res, errs = append(res, zero), append(errs, nil)
+		mu.Unlock()
+		jobs <- job{i: n, f: f}
+		n++
+		return nil
+	})
+	close(jobs)
+	wg.Wait()
+
+	return res, errs, err
+}
@@ -0,0 +1,114 @@
+package parse_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/fetch"
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/parse"
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/tarball"
+	"github.com/stretchr/testify/require"
+)
+
+// syntheticModules builds n modules with realistic prose.
+func syntheticModules(tb testing.TB, n int) []tarball.File {
+	tb.Helper()
+	content := lines(
+		`Sets the <value>size</value> of the buffer, see <link id="test"/>.`,
+		`<list type="tag">`,
+		`<tag-name><literal>backlog</literal>=<value>number</value></tag-name>`,
+		`<tag-desc>sets the backlog (1.9.1) in the <link doc="test.xml">other</link> call</tag-desc>`,
+		`<tag-name><literal>reuseport</literal></tag-name>`,
+		`<tag-desc><para>creates a socket</para><example>listen 80 reuseport;</example></tag-desc>`,
+		`</list>`,
+		`<note>Available as part of the <commercial_version>commercial subscription</commercial_version>.</note>`,
+	)
+	files := make([]tarball.File, 0, n)
+	for i := range n {
+		files = append(files, testModuleFile(tb,
+			withPath(fmt.Sprintf("/xml/en/docs/m%03d.xml", i)),
+			withSyntax(`<value>address</value>[:<value>port</value>] [<literal>backlog</literal>=<value>number</value>] [<literal>reuseport</literal>]`, false),
+			withContent(content),
+		))
+	}
+	return files
+}
+
+func TestParse_Workers(t *testing.T) {
+	t.Parallel()
+	files := syntheticModules(t, 50)
+
+	for _, workers := range []int{1, 4, 100} {
+		t.Run(fmt.Sprint(workers), func(t *testing.T) {
+			t.Parallel()
+			ref, err := parse.Parse(files, baseURL, upsellURL, parse.WithWorkers(workers))
+			require.NoError(t, err)
+			require.Len(t, ref.Modules, len(files))
+			for i, m := range ref.Modules {
+				require.Equal(t, fmt.Sprintf("/en/docs/m%03d.html", i), m.Link, "modules are in file order")
+			}
+		})
+	}
+
+	t.Run("first error wins", func(t *testing.T) {
+		t.Parallel()
+		broken := append([]tarball.File{}, files...)
+		broken[10] = testModuleFile(t, withPath("/xml/en/docs/broken10.xml"), withContent(`<list type="unknown"/>`))
+		broken[40] = testModuleFile(t, withPath("/xml/en/docs/broken40.xml"), withContent(`<list type="unknown"/>`))
+		_, err := parse.Parse(broken, baseURL, upsellURL, parse.WithWorkers(8))
+		require.ErrorContains(t, err, "broken10.xml")
+	})
+}
+
+// nginxOrgURL is the tarball of the real docs, the default -src.
+const nginxOrgURL = "https://github.com/nginx/nginx.org/archive/refs/heads/main.tar.gz"
+
+// nginxOrgTarball returns NGINX_ORG_TARBALL, or a copy of nginxOrgURL cached
+// in the user cache dir, only downloaded again when it changed. It skips the
+// benchmark when neither is available, e.g. offline.
+func nginxOrgTarball(b *testing.B) string {
+	b.Helper()
+	if path := os.Getenv("NGINX_ORG_TARBALL"); path != "" {
+		return path
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		b.Skipf("no cache dir for the nginx.org tarball, set NGINX_ORG_TARBALL: %v", err)
+	}
+	path, err := fetch.Cached(context.Background(), nginxOrgURL,
+		fetch.WithCacheDir(filepath.Join(dir, "reference-converter")), fetch.WithRetries(1))
+	if err != nil {
+		b.Skipf("unable to download the nginx.org tarball, set NGINX_ORG_TARBALL: %v", err)
+	}
+	return path
+}
+
+// BenchmarkParse compares worker counts on synthetic modules and on the real
+// docs from nginx.org, e.g.
+//
+//	go test -run ^$ -bench Parse -cpu 1,4 ./internal/parse
+func BenchmarkParse(b *testing.B) {
+	counts := slices.Compact(slices.Sorted(slices.Values([]int{1, 2, 4, runtime.GOMAXPROCS(0)})))
+	bench := func(b *testing.B, files []tarball.File) {
+		for _, workers := range counts {
+			b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+				for b.Loop() {
+					_, err := parse.Parse(files, baseURL, upsellURL, parse.WithWorkers(workers))
+					require.NoError(b, err)
+				}
+			})
+		}
+	}
+
+	b.Run("synthetic", func(b *testing.B) { bench(b, syntheticModules(b, 200)) })
+	b.Run("nginx.org", func(b *testing.B) {
+		files, err := tarball.Open(context.Background(), nginxOrgTarball(b))
+		require.NoError(b, err)
+		bench(b, files)
+	})
+}
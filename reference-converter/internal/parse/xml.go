@@ -3,8 +3,12 @@ package parse
 import (
 	"bytes"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"log/slog"
+	"path"
+	"slices"
+	"strconv"
 
 	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/tarball"
 )
@@ -21,17 +25,48 @@ func newAttrs(attrs []xml.Attr) attrMap {
 	return ret
 }
 
-// unmarshalXML works like xml.Unmarshal, but configured to handle the HTML
-// entities we see in NGINX docs and other quirks in the XML.
-func unmarshalXML(v any, f tarball.File) error {
-	// some files are missing a closing tag
-	if f.Contains("<module") && !f.Contains("</module>") {
-		slog.Warn("fixed missing </module>", slog.String("file", f.Name))
-		f.Contents = append(f.Contents, []byte("</module>")...)
+// rev returns the rev attribute, zero when missing.
+func (a attrMap) rev() (int, error) {
+	if a["rev"] == "" {
+		return 0, nil
+	}
+	rev, err := strconv.Atoi(a["rev"])
+	if err != nil {
+		return 0, fmt.Errorf("invalid rev %q: %w", a["rev"], err)
+	}
+	return rev, nil
+}
+
+// parseContext is what decoding needs beyond the XML it reads, like where the
+// docs live or links to other pages.
+//
+// xml.Decoder.Decode gives no way to pass contextual information, UnmarshalXML
+// implementations only get the *xml.Decoder. Deep in the XML tree we need to
+// know things like the base URL or an attribute from another XML file via
+// relative path. So the elements are decoded by hand from unmarshalXML down,
+// each handing the context to its children with decodeXML, see contextual.
+type parseContext struct {
+	ref  *Reference
+	page page // file being parsed, used to build links
+}
+
+// getPage looks up another page, given relative path from the current page.
+func (c *parseContext) getPage(relpath string) (page, bool) {
+	if relpath == "" {
+		return page{}, false
 	}
+	p := path.Join(path.Dir(c.page.path), relpath)
+	page, ok := c.ref.pages[p]
+	return page, ok
+}
 
-	decoder := xml.NewDecoder(bytes.NewReader(f.Contents))
-	decoder.Entity = map[string]string{
+// errNoContext means an element needing the parse context was decoded without
+// one, e.g. with xml.Unmarshal. Its links would miss the base URL.
+var errNoContext = errors.New("no parse context, decode with unmarshalXML")
+
+func newDecoder(f tarball.File) *xml.Decoder {
+	d := xml.NewDecoder(bytes.NewReader(f.Contents))
+	d.Entity = map[string]string{
 		"nbsp":  " ",
 		"mdash": "—",
 		"ldquo": "“",
@@ -40,9 +75,51 @@ func unmarshalXML(v any, f tarball.File) error {
 		"rsquo": "’",
 		"times": "×",
 	}
+	return d
+}
+
+// decodeChildren calls fn for each child element of the element being decoded,
+// up to its end. fn must consume the child, e.g. with d.DecodeElement or d.Skip.
+func decodeChildren(d *xml.Decoder, fn func(child xml.StartElement) error) error {
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if err := fn(t); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// unmarshalXML decodes the root element of f into v with the context,
+// configured to handle the HTML entities we see in NGINX docs and other quirks
+// in the XML.
+func unmarshalXML(v contextual, f tarball.File, ctx *parseContext) error {
+	// some files are missing a closing tag
+	if f.Contains("<module") && !f.Contains("</module>") {
+		slog.Warn("fixed missing </module>", slog.String("file", f.Name))
+		// copy, f shares its contents with the caller, who may parse it
+		// concurrently
+		f.Contents = slices.Concat(f.Contents, []byte("</module>"))
+	}
 
-	if err := decoder.Decode(v); err != nil {
-		return fmt.Errorf("unable to parse %s: %w", f.Name, err)
+	d := newDecoder(f)
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return fmt.Errorf("unable to parse %s: %w", f.Name, err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			if err := v.decodeXML(d, start, ctx); err != nil {
+				return fmt.Errorf("unable to parse %s: %w", f.Name, err)
+			}
+			return nil
+		}
 	}
-	return nil
 }
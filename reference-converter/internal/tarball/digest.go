@@ -0,0 +1,48 @@
+package tarball
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Digest returns the hex SHA-256 of the local source at path. For an archive,
+// it is the digest of the file, like sha256sum. For a directory, it is the
+// digest of a "<sha256>  <path>\n" line for every xml file Walk yields, in
+// order, so it covers exactly what the converter reads. Paths are relative to
+// the directory, so checkouts in directories with other names match.
+func Digest(ctx context.Context, path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	if info.IsDir() {
+		base := filepath.Base(filepath.Clean(path)) + "/"
+		err := walkDir(ctx, path, func(f File) error {
+			sum := sha256.Sum256(f.Contents)
+			_, err := fmt.Fprintf(h, "%x  %s\n", sum, strings.TrimPrefix(f.Name, base))
+			return err
+		})
+		if err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	f, err := os.Open(path) //nolint:gosec // the user picks the source
+	if err != nil {
+		return "", err
+	}
+	defer f.Close() //nolint:errcheck // nothing to do about it
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("unable to read %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,163 @@
+package tarball
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// Format is the kind of archive holding the docs, detected from the first
+// bytes rather than the file name, since URLs rarely end with a suffix.
+type Format string
+
+const (
+	FormatUnknown Format = "unknown"
+	FormatTar     Format = "tar"
+	FormatGzip    Format = "gzip"
+	FormatXz      Format = "xz"
+	FormatBzip2   Format = "bzip2"
+	FormatZstd    Format = "zstd"
+	FormatZip     Format = "zip"
+)
+
+// sniffLen covers the "ustar" magic of tar headers at offset 257.
+const sniffLen = 262
+
+var magics = []struct {
+	format Format
+	offset int
+	magic  []byte
+}{
+	{FormatGzip, 0, []byte{0x1f, 0x8b}},
+	{FormatXz, 0, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{FormatBzip2, 0, []byte("BZh")},
+	{FormatZstd, 0, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	{FormatZip, 0, []byte("PK\x03\x04")},
+	{FormatZip, 0, []byte("PK\x05\x06")}, // empty zip
+	{FormatTar, 257, []byte("ustar")},
+}
+
+// detect finds the format of an archive from its first bytes.
+func detect(head []byte) Format {
+	for _, m := range magics {
+		if len(head) >= m.offset+len(m.magic) && bytes.Equal(head[m.offset:m.offset+len(m.magic)], m.magic) {
+			return m.format
+		}
+	}
+	return FormatUnknown
+}
+
+// decompress unwraps a compressed tar.
+func decompress(format Format, r io.Reader) (io.ReadCloser, error) {
+	switch format {
+	case FormatGzip:
+		return gzip.NewReader(r)
+	case FormatXz:
+		xr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(xr), nil
+	case FormatBzip2:
+		return io.NopCloser(bzip2.NewReader(r)), nil
+	case FormatZstd:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("%s is not a compression format", format)
+	}
+}
+
+// walkZip reads a zip archive. Zip keeps its index at the end, so the whole
+// archive is read into memory first.
+func walkZip(ctx context.Context, r io.Reader, log *slog.Logger, fn func(File) error) error {
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read zip: %w", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf), int64(len(buf)))
+	if err != nil {
+		return fmt.Errorf("failed to read zip: %w", err)
+	}
+
+	log.DebugContext(ctx, "reading zip")
+	defer log.DebugContext(ctx, "done reading")
+	for _, zf := range zr.File {
+		// stop if the context is canceled
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !zf.Mode().IsRegular() || !strings.HasSuffix(zf.Name, ".xml") {
+			continue
+		}
+		contents, err := readZipFile(zf)
+		if err != nil {
+			return fmt.Errorf("failed to read %s contents: %w", zf.Name, err)
+		}
+		if err := fn(File{Name: zf.Name, Contents: contents}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readZipFile(zf *zip.File) ([]byte, error) {
+	rc, err := zf.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close() //nolint:errcheck // nothing to do about it
+	return io.ReadAll(rc)
+}
+
+// walkDir reads the xml files of a directory tree in lexical order. Names
+// start with the directory name, like the top directory of a tarball, e.g.
+// "nginx.org/xml/en/docs/index.xml".
+func walkDir(ctx context.Context, root string, fn func(File) error) error {
+	log := slog.With(slog.String("dir", root))
+	log.DebugContext(ctx, "reading directory")
+	defer log.DebugContext(ctx, "done reading")
+
+	base := filepath.Base(filepath.Clean(root))
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// stop if the context is canceled
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() && strings.HasPrefix(d.Name(), ".") && p != root {
+			return filepath.SkipDir // e.g. .git
+		}
+		if !d.Type().IsRegular() || !strings.HasSuffix(p, ".xml") {
+			return nil
+		}
+
+		contents, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		return fn(File{Name: path.Join(base, filepath.ToSlash(rel)), Contents: contents})
+	})
+}
@@ -0,0 +1,121 @@
+package tarball_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/tarball"
+	"github.com/stretchr/testify/require"
+	"github.com/ulikunitz/xz"
+)
+
+var wantFiles = []tarball.File{
+	{Name: "docs/xml/bar.xml", Contents: []byte("bar\n")},
+	{Name: "docs/xml/foo.xml", Contents: []byte("foo\n")},
+}
+
+func tarArchive(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range wantFiles {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: f.Name, Mode: 0o644, Size: int64(len(f.Contents)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write(f.Contents)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "docs/README.md", Mode: 0o644, Typeflag: tar.TypeReg}))
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func compressed(t *testing.T, newWriter func(io.Writer) (io.WriteCloser, error)) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := newWriter(&buf)
+	require.NoError(t, err)
+	_, err = w.Write(tarArchive(t))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range wantFiles {
+		w, err := zw.Create(f.Name)
+		require.NoError(t, err)
+		_, err = w.Write(f.Contents)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestWalk_Formats(t *testing.T) {
+	t.Parallel()
+	testcases := map[string][]byte{
+		"tar":  tarArchive(t),
+		"gzip": compressed(t, func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil }),
+		"xz":   compressed(t, func(w io.Writer) (io.WriteCloser, error) { return xz.NewWriter(w) }),
+		"zstd": compressed(t, func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) }),
+		"zip":  zipArchive(t),
+	}
+	// there is no bzip2 writer in the standard library
+	if bzip2, err := exec.LookPath("bzip2"); err == nil {
+		cmd := exec.Command(bzip2, "-c")
+		cmd.Stdin = bytes.NewReader(tarArchive(t))
+		out, err := cmd.Output()
+		require.NoError(t, err)
+		testcases["bzip2"] = out
+	}
+
+	for name, archive := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			// no suffix, the format is detected from the contents
+			p := filepath.Join(t.TempDir(), "archive")
+			require.NoError(t, os.WriteFile(p, archive, 0o600))
+
+			files, err := tarball.Open(context.Background(), p)
+			require.NoError(t, err)
+			require.ElementsMatch(t, wantFiles, files)
+		})
+	}
+}
+
+func TestWalk_Dir(t *testing.T) {
+	t.Parallel()
+	root := filepath.Join(t.TempDir(), "docs")
+	for _, f := range wantFiles {
+		p := filepath.Join(filepath.Dir(root), filepath.FromSlash(f.Name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, f.Contents, 0o600))
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".git"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".git", "skipped.xml"), nil, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "README.md"), nil, 0o600))
+
+	files, err := tarball.Open(context.Background(), root)
+	require.NoError(t, err)
+	require.Equal(t, wantFiles, files, "in lexical order")
+}
+
+func TestWalk_Unknown(t *testing.T) {
+	t.Parallel()
+	p := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, os.WriteFile(p, []byte("not an archive"), 0o600))
+
+	_, err := tarball.Open(context.Background(), p)
+	require.ErrorContains(t, err, "invalid source")
+}
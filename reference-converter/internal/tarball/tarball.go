@@ -2,7 +2,8 @@ package tarball
 
 import (
 	"archive/tar"
-	"compress/gzip"
+	"bufio"
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -11,6 +12,8 @@ import (
 	"net/http"
 	"os"
 	"strings"
+
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/fetch"
 )
 
 type File struct {
@@ -19,79 +22,131 @@ type File struct {
 }
 
 // Contains reports whether substr is in this file's contents
-func (f *File) Contains(substr string) bool { return strings.Contains(string(f.Contents), substr) }
+func (f *File) Contains(substr string) bool { return bytes.Contains(f.Contents, []byte(substr)) }
 
 type config struct {
-	Client http.Client
+	fetch []fetch.Option
 }
 type Option = func(*config)
 
 // WithHttpClient uses the provided client instead of the default for
 // downloading tarballs.
 func WithHttpClient(c http.Client) Option {
-	return func(o *config) { o.Client = c }
+	return WithFetch(fetch.WithHttpClient(c))
+}
+
+// WithFetch configures downloads, e.g. to go through a cache.
+func WithFetch(opts ...fetch.Option) Option {
+	return func(o *config) { o.fetch = append(o.fetch, opts...) }
 }
 
-// Open reads a tarball from the given path or url, and returns a slice of all
-// the xml files inside.
+// Open reads the docs from the given path or url, and returns a slice of all
+// the xml files inside. Use Walk to avoid keeping every file in memory.
 func Open(ctx context.Context, pathOrURL string, opts ...Option) ([]File, error) {
-	if !strings.HasSuffix(pathOrURL, ".tar.gz") {
-		return nil, errors.New("invalid source, must be a tar.gz")
+	var res []File
+	err := Walk(ctx, pathOrURL, func(f File) error {
+		res = append(res, f)
+		return nil
+	}, opts...)
+	if err != nil {
+		return nil, err
 	}
+	return res, nil
+}
 
-	if _, err := os.Stat(pathOrURL); err == nil {
-		return openFile(ctx, pathOrURL)
+// Walk reads the docs from the given path or url, calling fn for each xml file
+// inside, in order. Files are not kept once fn returns. It stops at the first
+// error from fn, and returns it.
+//
+// The source is either a local directory, like a checkout of nginx.org, or an
+// archive detected from its contents, see Format.
+func Walk(ctx context.Context, pathOrURL string, fn func(File) error, opts ...Option) error {
+	if info, err := os.Stat(pathOrURL); err == nil {
+		if info.IsDir() {
+			return walkDir(ctx, pathOrURL, fn)
+		}
+		return walkFile(ctx, pathOrURL, fn)
 	}
 
 	cfg := &config{}
 	for _, opt := range opts {
 		opt(cfg)
 	}
-	return openURL(ctx, pathOrURL, cfg.Client)
+	return walkURL(ctx, pathOrURL, cfg.fetch, fn)
 }
 
-func openURL(ctx context.Context, url string, client http.Client) ([]File, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
+// Download saves the tarball at url into dst, so it can be walked more than
+// once without downloading it again.
+func Download(ctx context.Context, url string, dst io.Writer, opts ...Option) error {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
 	}
-	res, err := client.Do(req)
+	body, err := fetch.Get(ctx, url, cfg.fetch...)
 	if err != nil {
-		return nil, fmt.Errorf("unabled to download %s: %w", url, err)
+		return err
 	}
-	defer res.Body.Close() //nolint:errcheck // nothing to do about it
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unabled to download %s: %s", url, res.Status)
+	defer body.Close() //nolint:errcheck // nothing to do about it
+	if _, err := io.Copy(dst, body); err != nil {
+		return fmt.Errorf("unabled to download %s: %w", url, err)
 	}
-	return open(ctx, res.Body, slog.With(slog.String("url", url)))
+	return nil
 }
 
-func openFile(ctx context.Context, path string) ([]File, error) {
+func walkURL(ctx context.Context, url string, opts []fetch.Option, fn func(File) error) error {
+	body, err := fetch.Get(ctx, url, opts...)
+	if err != nil {
+		return err
+	}
+	defer body.Close() //nolint:errcheck // nothing to do about it
+	return walk(ctx, body, slog.With(slog.String("url", url)), fn)
+}
+
+func walkFile(ctx context.Context, path string, fn func(File) error) error {
 	f, err := os.Open(path)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer f.Close() //nolint:errcheck // nothing to do about it
-	return open(ctx, f, slog.With(slog.String("path", path)))
+	return walk(ctx, f, slog.With(slog.String("path", path)), fn)
 }
 
-func open(ctx context.Context, raw io.Reader, log *slog.Logger) ([]File, error) {
-	log.DebugContext(ctx, "opening tarball")
-	gz, err := gzip.NewReader(raw)
+func walk(ctx context.Context, raw io.Reader, log *slog.Logger, fn func(File) error) error {
+	log.DebugContext(ctx, "opening archive")
+	br := bufio.NewReaderSize(raw, sniffLen)
+	// a short read just means a small file
+	head, _ := br.Peek(sniffLen)
+	format := detect(head)
+	log = log.With(slog.String("format", string(format)))
+
+	switch format {
+	case FormatTar:
+		return walkTar(ctx, br, log, fn)
+	case FormatZip:
+		return walkZip(ctx, br, log, fn)
+	case FormatUnknown:
+		return errors.New("invalid source, must be a directory, tar or zip archive")
+	}
+
+	// compressed tar, e.g. .tar.gz
+	r, err := decompress(format, br)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to decompress %s: %w", format, err)
 	}
-	defer gz.Close() //nolint:errcheck // nothing to do about it
+	defer r.Close() //nolint:errcheck // nothing to do about it
+	return walkTar(ctx, r, log, fn)
+}
 
-	tr := tar.NewReader(gz)
+func walkTar(ctx context.Context, r io.Reader, log *slog.Logger, fn func(File) error) error {
+	tr := tar.NewReader(r)
 
 	log.DebugContext(ctx, "reading tarball")
 	defer log.DebugContext(ctx, "done reading")
-	var res []File
+	n := 0
 	for {
 		// stop if the context is canceled
 		if err := ctx.Err(); err != nil {
-			return nil, err
+			return err
 		}
 
 		header, err := tr.Next()
@@ -100,7 +155,7 @@ func open(ctx context.Context, raw io.Reader, log *slog.Logger) ([]File, error)
 			break
 		}
 		if err != nil {
-			return nil, fmt.Errorf("failed to read next tarball entry: %w", err)
+			return fmt.Errorf("failed to read next tarball entry: %w", err)
 		}
 
 		// we only care about regular files
@@ -115,11 +170,13 @@ func open(ctx context.Context, raw io.Reader, log *slog.Logger) ([]File, error)
 
 		buf, err := io.ReadAll(tr)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read %s contents: %w", header.Name, err)
+			return fmt.Errorf("failed to read %s contents: %w", header.Name, err)
 		}
-		res = append(res, File{Name: header.Name, Contents: buf})
-
+		if err := fn(File{Name: header.Name, Contents: buf}); err != nil {
+			return err
+		}
+		n++
 	}
-	log.DebugContext(ctx, "read tarball", slog.Int("numFiles", len(res)))
-	return res, nil
+	log.DebugContext(ctx, "read tarball", slog.Int("numFiles", n))
+	return nil
 }
@@ -1,9 +1,15 @@
 package tarball_test
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/tarball"
@@ -37,3 +43,82 @@ func TestOpen_Url(t *testing.T) {
 		{Name: "bar.xml", Contents: []byte("bar\n")},
 	})
 }
+
+func TestWalk(t *testing.T) {
+	t.Parallel()
+	var names []string
+	err := tarball.Walk(context.Background(), "./testdata/test.tar.gz", func(f tarball.File) error {
+		names = append(names, f.Name)
+		return nil
+	})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"foo.xml", "bar.xml"}, names)
+
+	stop := errors.New("stop")
+	calls := 0
+	err = tarball.Walk(context.Background(), "./testdata/test.tar.gz", func(f tarball.File) error {
+		calls++
+		return stop
+	})
+	require.ErrorIs(t, err, stop)
+	require.Equal(t, 1, calls, "stops at the first error")
+}
+
+func TestDownload(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.FileServer(http.Dir("./testdata")))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	require.NoError(t, tarball.Download(context.Background(), srv.URL+"/test.tar.gz", &buf, tarball.WithHttpClient(*srv.Client())))
+	want, err := os.ReadFile("./testdata/test.tar.gz")
+	require.NoError(t, err)
+	require.Equal(t, want, buf.Bytes())
+
+	err = tarball.Download(context.Background(), srv.URL+"/missing.tar.gz", &buf, tarball.WithHttpClient(*srv.Client()))
+	require.ErrorContains(t, err, "404")
+}
+
+func TestFile_Contains(t *testing.T) {
+	t.Parallel()
+	f := tarball.File{Contents: []byte(`<!DOCTYPE module SYSTEM "../../../dtd/module.dtd">`)}
+	require.True(t, f.Contains("dtd/module.dtd"))
+	require.False(t, f.Contains("dtd/article.dtd"))
+}
+
+func TestDigest(t *testing.T) {
+	t.Parallel()
+	// sha256sum testdata/test.tar.gz
+	contents, err := os.ReadFile("./testdata/test.tar.gz")
+	require.NoError(t, err)
+	want := sha256.Sum256(contents)
+
+	got, err := tarball.Digest(context.Background(), "./testdata/test.tar.gz")
+	require.NoError(t, err)
+	require.Equal(t, hex.EncodeToString(want[:]), got)
+
+	// directories hash the xml files they hold
+	dir := filepath.Join(t.TempDir(), "docs")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "foo.xml"), []byte("foo\n"), 0o600))
+	before, err := tarball.Digest(context.Background(), dir)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("ignored"), 0o600))
+	same, err := tarball.Digest(context.Background(), dir)
+	require.NoError(t, err)
+	require.Equal(t, before, same, "only xml files count")
+
+	// the name of the checkout doesn't count
+	other := filepath.Join(t.TempDir(), "nginx.org")
+	require.NoError(t, os.MkdirAll(other, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(other, "foo.xml"), []byte("foo\n"), 0o600))
+	renamed, err := tarball.Digest(context.Background(), other)
+	require.NoError(t, err)
+	require.Equal(t, before, renamed)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "foo.xml"), []byte("changed\n"), 0o600))
+	after, err := tarball.Digest(context.Background(), dir)
+	require.NoError(t, err)
+	require.NotEqual(t, before, after)
+}
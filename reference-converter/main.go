@@ -1,14 +1,22 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"log/slog"
+	"net/url"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
 	"syscall"
 
 	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/atom"
+	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/fetch"
 	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/output"
 	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/parse"
 	"github.com/nginxinc/nginx-directive-reference/reference-converter/internal/tarball"
@@ -16,14 +24,53 @@ import (
 
 var (
 	destFlag      = flag.String("dst", "reference.json", "where to write JSON output")
-	sourceFlag    = flag.String("src", "https://github.com/nginx/nginx.org/archive/refs/heads/main.tar.gz", "where to get the XML sources")
-	feedURLFlag   = flag.String("feed-url", "https://github.com/nginx/nginx.org/commits/main.atom", "where to get the atom feed for XML changes")
+	sourceFlag    = flag.String("src", "https://github.com/nginx/nginx.org/archive/refs/heads/main.tar.gz", "where to get the XML sources: a directory, or a path or URL to a tar, zip or compressed tar archive")
+	feedURLFlag   = flag.String("feed-url", "https://github.com/nginx/nginx.org/commits/main.atom", "where to get the atom feed for XML changes, leave empty for no version")
 	baseURLFlag   = flag.String("base-url", "https://nginx.org", "base URL for rendering links inside the docs")
-	upsellURLFlag = flag.String("upsell-url", "https://nginx.com/products/", "URL for linking people to NGINX+")
+	upsellURLFlag = flag.String("upsell-url", "https://nginx.com/products/", "URL for linking people to NGINX+, leave empty for no links")
+	editionFlag   = flag.String("edition", string(output.EditionAll), "which docs to keep: oss drops commercial-only ones, plus keeps only them, or all")
+	langFlag      = flag.String("lang", output.DefaultLang, "language of the docs, or \"all\" for every language keyed by language")
+	reportFlag    = flag.String("report", "", "write a report to dst instead of the reference, one of: translations")
+	nginxVerFlag  = flag.String("nginx-version", "", "only include what is available in this NGINX version, e.g. 1.18.0")
+	articlesFlag  = flag.Bool("articles", true, "include the articles (guides under docs/) next to the modules")
+	workersFlag   = flag.Int("workers", 1, "how many XML files to parse at once")
+	cacheDirFlag  = flag.String("cache-dir", "", "where to cache downloads between runs, only downloading again when they changed")
+	offlineFlag   = flag.Bool("offline", false, "use the copies in -cache-dir without any network access")
+	fetchTimeout  = flag.Duration("fetch-timeout", fetch.DefaultTimeout, "how long to wait for each download attempt, 0 for no limit")
+	fetchRetries  = flag.Int("fetch-retries", fetch.DefaultRetries, "how many times to retry network errors, 5xx and 429 responses")
+	tokenEnvFlag  = flag.String("token-env", "NGINX_DOCS_TOKEN", "environment variable with a bearer token to authenticate downloads, unset for none")
+	authHostsFlag = flag.String("auth-hosts", "", "comma separated hosts to send the token and -header values to, defaults to the host of -src")
+	caBundleFlag  = flag.String("ca-bundle", "", "PEM file with extra CAs to trust for downloads, e.g. for an internal mirror")
+	proxyFlag     = flag.String("proxy", "", "proxy URL for downloads, defaults to HTTP_PROXY, HTTPS_PROXY and NO_PROXY")
+	ifChangedFlag = flag.String("if-changed", "", "existing reference JSON, exit early with code 3 when its version is the latest commit")
+	srcSHA256Flag = flag.String("src-sha256", "", "expected SHA-256 of -src, fails the run when it differs; directories hash their xml files")
+	capiDestFlag  = flag.String("capi-dst", "", "where to write the C API from the development guide as JSON, leave empty to skip it")
 )
 
+// headerFlags collects repeated -header flags.
+type headerFlags []string
+
+func (h *headerFlags) String() string     { return strings.Join(*h, ", ") }
+func (h *headerFlags) Set(v string) error { *h = append(*h, v); return nil }
+
+var headersFlag headerFlags
+
+func init() {
+	flag.Var(&headersFlag, "header", "extra `Name: value` header for downloads, can be repeated")
+}
+
+// exitUnchanged is the exit code when -if-changed finds nothing new, so
+// scripts can tell it apart from errors.
+const exitUnchanged = 3
+
+// errUnchanged stops the run when the docs are the same as in -if-changed.
+var errUnchanged = errors.New("docs unchanged")
+
 func main() {
 	err := runConverter()
+	if errors.Is(err, errUnchanged) {
+		os.Exit(exitUnchanged)
+	}
 	if err != nil {
 		os.Exit(1)
 	}
@@ -37,46 +84,322 @@ func runConverter() error {
 	defer stop()
 
 	flag.Parse()
+	if *reportFlag != "" && *reportFlag != "translations" {
+		err := fmt.Errorf("unknown -report %q", *reportFlag)
+		slog.ErrorContext(ctx, "bad flags", slog.Any("error", err))
+		return err
+	}
+	if *reportFlag != "" && *ifChangedFlag != "" {
+		err := errors.New("-if-changed compares references, not reports")
+		slog.ErrorContext(ctx, "bad flags", slog.Any("error", err))
+		return err
+	}
+	edition := output.Edition(*editionFlag)
+	if !slices.Contains(output.Editions, edition) {
+		err := fmt.Errorf("unknown -edition %q", *editionFlag)
+		slog.ErrorContext(ctx, "bad flags", slog.Any("error", err))
+		return err
+	}
+	// no upselling in the open source docs
+	upsellURL := *upsellURLFlag
+	if edition == output.EditionOSS {
+		upsellURL = ""
+	}
+	if *workersFlag < 1 {
+		err := fmt.Errorf("-workers must be at least 1, got %d", *workersFlag)
+		slog.ErrorContext(ctx, "bad flags", slog.Any("error", err))
+		return err
+	}
+	if *fetchRetries < 0 || *fetchTimeout < 0 {
+		err := errors.New("-fetch-retries and -fetch-timeout can't be negative")
+		slog.ErrorContext(ctx, "bad flags", slog.Any("error", err))
+		return err
+	}
+	if *offlineFlag && *cacheDirFlag == "" {
+		err := errors.New("-offline needs a -cache-dir")
+		slog.ErrorContext(ctx, "bad flags", slog.Any("error", err))
+		return err
+	}
+	if *nginxVerFlag != "" && !output.IsValidVersion(*nginxVerFlag) {
+		err := fmt.Errorf("invalid -nginx-version %q", *nginxVerFlag)
+		slog.ErrorContext(ctx, "bad flags", slog.Any("error", err))
+		return err
+	}
 
 	slog.InfoContext(ctx, "started", slog.Group("opts",
 		slog.String("dst", *destFlag),
 		slog.String("src", *sourceFlag),
 		slog.String("feed-url", *feedURLFlag),
-		slog.String("base-url", *baseURLFlag)))
+		slog.String("base-url", *baseURLFlag),
+		slog.String("lang", *langFlag),
+		slog.String("report", *reportFlag),
+		slog.String("edition", *editionFlag),
+		slog.String("nginx-version", *nginxVerFlag),
+		slog.Bool("articles", *articlesFlag),
+		slog.Int("workers", *workersFlag),
+		slog.String("cache-dir", *cacheDirFlag),
+		slog.Bool("offline", *offlineFlag),
+		slog.Duration("fetch-timeout", *fetchTimeout),
+		slog.Int("fetch-retries", *fetchRetries),
+		slog.String("token-env", *tokenEnvFlag),
+		slog.String("auth-hosts", *authHostsFlag),
+		slog.Int("headers", len(headersFlag)), // values may be secrets
+		slog.String("ca-bundle", *caBundleFlag),
+		slog.String("proxy", *proxyFlag),
+		slog.String("if-changed", *ifChangedFlag),
+		slog.String("src-sha256", *srcSHA256Flag),
+		slog.String("capi-dst", *capiDestFlag)))
 	defer slog.InfoContext(ctx, "finished")
 
-	v1, err := atom.GetVersion(ctx, *feedURLFlag)
+	fetchOpts, err := fetchOptions(ctx)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to get the version", slog.Any("error", err), slog.String("src", *feedURLFlag))
+		slog.ErrorContext(ctx, "bad flags", slog.Any("error", err))
+		return err
+	}
+	// the latest commit says which version of the docs this is
+	var latest atom.Entry
+	if *feedURLFlag != "" {
+		latest, err = atom.GetLatest(ctx, *feedURLFlag, atom.WithFetch(fetchOpts...))
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to get the version", slog.Any("error", err), slog.String("src", *feedURLFlag))
+			return err
+		}
+	}
+	v1 := latest.Commit()
+	slog.InfoContext(ctx, "docs version",
+		slog.String("commit", v1),
+		slog.Time("updated", latest.Updated),
+		slog.String("title", latest.Title),
+		slog.String("author", latest.Author.Name))
+
+	if *ifChangedFlag != "" {
+		want := output.Settings{
+			FormatVersion: output.FormatVersion,
+			Lang:          *langFlag,
+			NginxVersion:  *nginxVerFlag,
+			Edition:       edition,
+			Articles:      *articlesFlag,
+		}
+		if err := checkChanged(ctx, *ifChangedFlag, v1, want); err != nil {
+			return err
+		}
+	}
+
+	// the parser reads the sources twice, only download them once
+	src, cleanup, err := localSource(ctx, *sourceFlag, fetchOpts)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to download", slog.Any("error", err), slog.String("src", *sourceFlag))
+		return err
 	}
-	slog.InfoContext(ctx, "Comparing Versions", slog.String("atom", v1))
+	defer cleanup()
 
-	// unpack the tarball
-	files, err := tarball.Open(ctx, *sourceFlag)
+	// prove which input produced the reference
+	digest, err := tarball.Digest(ctx, src)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to read", slog.Any("error", err), slog.String("src", *sourceFlag))
+		slog.ErrorContext(ctx, "failed to hash the source", slog.Any("error", err), slog.String("src", *sourceFlag))
+		return err
+	}
+	if *srcSHA256Flag != "" && !strings.EqualFold(digest, *srcSHA256Flag) {
+		err := fmt.Errorf("source SHA-256 is %s, want %s", digest, *srcSHA256Flag)
+		slog.ErrorContext(ctx, "source verification failed", slog.Any("error", err), slog.String("src", *sourceFlag))
 		return err
 	}
+	slog.InfoContext(ctx, "source digest", slog.String("sha256", digest))
 
 	// reading files, converts XML to markdown
-	r, err := parse.Parse(files, *baseURLFlag, *upsellURLFlag)
+	walk := func(ctx context.Context, fn func(tarball.File) error) error {
+		return tarball.Walk(ctx, src, fn)
+	}
+	r, err := parse.ParseSource(ctx, walk, *baseURLFlag, upsellURL, parse.WithWorkers(*workersFlag))
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to parse", slog.Any("error", err))
 		return err
 	}
-	slog.InfoContext(ctx, "parsed into modules", slog.Int("n", len(r.Modules)))
+	slog.InfoContext(ctx, "parsed into modules", slog.Int("n", len(r.Modules)), slog.Int("articles", len(r.Articles)))
 
 	// convert XML types to JSON types
-	ref := output.New(v1, r.Modules)
+	outOpts := []output.Option{
+		output.WithEdition(edition),
+		output.WithCommitTime(latest.Updated),
+		output.WithSource(*sourceFlag, digest),
+	}
+	if *nginxVerFlag != "" {
+		outOpts = append(outOpts, output.WithNginxVersion(*nginxVerFlag))
+	}
+	if *articlesFlag {
+		outOpts = append(outOpts, output.WithArticles(r.Articles))
+	}
+	var ref writer
+	switch {
+	case *reportFlag == "translations":
+		ref = output.NewTranslationReport(v1, r.Modules, outOpts...)
+	case *langFlag == "all":
+		ref = output.NewMultilingual(v1, r.Modules, outOpts...)
+	default:
+		if !slices.Contains(output.Languages(r.Modules), *langFlag) {
+			err := fmt.Errorf("no docs in language %q", *langFlag)
+			slog.ErrorContext(ctx, "bad flags", slog.Any("error", err))
+			return err
+		}
+		ref = output.New(v1, r.Modules, append(outOpts, output.WithLang(*langFlag))...)
+	}
+
+	if err := writeFile(ctx, *destFlag, ref); err != nil {
+		return err
+	}
+
+	if *capiDestFlag != "" {
+		capi := output.NewCAPI(v1, r.Articles, outOpts...)
+		slog.InfoContext(ctx, "extracted the C API", slog.Int("n", len(capi.Symbols)))
+		if err := writeFile(ctx, *capiDestFlag, capi); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkChanged returns errUnchanged when the reference at path was built from
+// the commit version with the same settings: this version of the converter,
+// and the same -lang, -nginx-version, -edition and -articles. A missing
+// reference counts as changed.
+func checkChanged(ctx context.Context, path, version string, want output.Settings) error {
+	if version == "" {
+		err := errors.New("-if-changed needs a version from -feed-url")
+		slog.ErrorContext(ctx, "bad flags", slog.Any("error", err))
+		return err
+	}
+	b, err := os.ReadFile(path) //nolint:gosec // the user picks the file
+	if errors.Is(err, os.ErrNotExist) {
+		slog.InfoContext(ctx, "no existing reference, regenerating", slog.String("if-changed", path))
+		return nil
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to open the existing reference", slog.Any("error", err))
+		return err
+	}
+
+	existing, err := output.GetVersion(ctx, bytes.NewReader(b))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to read the existing reference", slog.Any("error", err))
+		return err
+	}
+	settings, err := output.GetSettings(ctx, bytes.NewReader(b))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to read the existing reference", slog.Any("error", err))
+		return err
+	}
+	if settings != want {
+		slog.InfoContext(ctx, "converter or flags changed, regenerating",
+			slog.Any("from", settings), slog.Any("to", want))
+		return nil
+	}
+	if existing == version {
+		slog.InfoContext(ctx, "docs unchanged, skipping", slog.String("version", version))
+		return errUnchanged
+	}
+	slog.InfoContext(ctx, "docs changed, regenerating", slog.String("from", existing), slog.String("to", version))
+	return nil
+}
+
+// fetchOptions configures downloads from the flags.
+func fetchOptions(ctx context.Context) ([]fetch.Option, error) {
+	client, err := fetch.NewClient(*caBundleFlag, *proxyFlag)
+	if err != nil {
+		return nil, err
+	}
+	opts := []fetch.Option{
+		fetch.WithHttpClient(client),
+		fetch.WithCacheDir(*cacheDirFlag),
+		fetch.WithOffline(*offlineFlag),
+		fetch.WithTimeout(*fetchTimeout),
+		fetch.WithRetries(*fetchRetries),
+	}
+
+	var creds []fetch.Option
+	if token := os.Getenv(*tokenEnvFlag); *tokenEnvFlag != "" && token != "" {
+		creds = append(creds, fetch.WithBearerToken(token))
+	}
+	for _, h := range headersFlag {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid -header %q, want \"Name: value\"", h)
+		}
+		creds = append(creds, fetch.WithHeader(strings.TrimSpace(name), strings.TrimSpace(value)))
+	}
+	if len(creds) == 0 {
+		return opts, nil
+	}
+	hosts := authHosts()
+	if len(hosts) == 0 {
+		slog.WarnContext(ctx, "not sending the token and headers, -src is local and there is no -auth-hosts")
+		return opts, nil
+	}
+	opts = append(opts, creds...)
+	return append(opts, fetch.WithHeaderHosts(hosts...)), nil
+}
+
+// authHosts are the hosts trusted with the credentials, from -auth-hosts or
+// the host of a -src URL.
+func authHosts() []string {
+	var hosts []string
+	for h := range strings.SplitSeq(*authHostsFlag, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			hosts = append(hosts, h)
+		}
+	}
+	if len(hosts) > 0 {
+		return hosts
+	}
+	if _, err := os.Stat(*sourceFlag); err == nil {
+		return nil
+	}
+	if u, err := url.Parse(*sourceFlag); err == nil && u.Host != "" {
+		return []string{u.Host}
+	}
+	return nil
+}
+
+// localSource downloads the archive at src into the cache, or a temporary
+// file without a cache, unless it is already a local file or directory.
+// cleanup removes the temporary file.
+func localSource(ctx context.Context, src string, opts []fetch.Option) (path string, cleanup func(), err error) {
+	if _, err := os.Stat(src); err == nil {
+		return src, func() {}, nil
+	}
+	if *cacheDirFlag != "" {
+		path, err := fetch.Cached(ctx, src, opts...)
+		return path, func() {}, err
+	}
+
+	tmp, err := os.CreateTemp("", "reference-converter-src-*")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup = func() { os.Remove(tmp.Name()) } //nolint:errcheck,gosec // nothing to do about it
+	err = tarball.Download(ctx, src, tmp, tarball.WithFetch(opts...))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return tmp.Name(), cleanup, nil
+}
+
+type writer interface {
+	Write(context.Context, io.Writer) error
+}
 
-	dst, err := os.Create(*destFlag)
+func writeFile(ctx context.Context, path string, w writer) error {
+	dst, err := os.Create(path)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to open dst", slog.Any("error", err))
+		slog.ErrorContext(ctx, "failed to open dst", slog.Any("error", err), slog.String("dst", path))
 		return err
 	}
 	defer dst.Close() //nolint:errcheck // nothing to do about it
-	if err := ref.Write(ctx, dst); err != nil {
-		slog.ErrorContext(ctx, "failed to save", slog.Any("error", err))
+	if err := w.Write(ctx, dst); err != nil {
+		slog.ErrorContext(ctx, "failed to save", slog.Any("error", err), slog.String("dst", path))
 		return err
 	}
 	return nil